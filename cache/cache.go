@@ -2,7 +2,9 @@ package cache
 
 import (
         "errors"
+        "reflect"
         "sync"
+        "time"
 
         "github.com/VVolf8/go-telegram-bot/core"
 )
@@ -14,36 +16,107 @@ type Cache interface {
         Delete(key string) error
 }
 
+// Option задаёт необязательные параметры кэша.
+type Option func(*MemoryCache)
+
+// WithCodec включает хранение значений в сериализованном виде: Set кодирует значение,
+// поэтому последующие изменения исходного объекта не влияют на кэш. Get в этом режиме
+// возвращает закодированные байты ([]byte), а типизированное значение читается через Load.
+func WithCodec(codec Codec) Option {
+        return func(mc *MemoryCache) {
+                mc.codec = codec
+        }
+}
+
+// ErrNoCodec возвращается Load, если кэш создан без WithCodec.
+var ErrNoCodec = errors.New("cache has no codec")
+
 // MemoryCache – простая реализация кэша в памяти.
 type MemoryCache struct {
         data   map[string]interface{}
         mu     sync.RWMutex
         logger core.Logger
+        codec  Codec
+        // expires хранит срок жизни записей, сохранённых через SetWithTTL.
+        expires map[string]time.Time
+        // nextSweep – момент следующей очистки просроченных записей.
+        nextSweep time.Time
 }
 
 // NewMemoryCache создаёт новый in-memory кэш с использованием переданного логгера.
-func NewMemoryCache(logger core.Logger) *MemoryCache {
-        return &MemoryCache{
-                data:   make(map[string]interface{}),
-                logger: logger,
+// Дополнительные параметры (например, WithCodec) передаются через opts.
+func NewMemoryCache(logger core.Logger, opts ...Option) *MemoryCache {
+        mc := &MemoryCache{
+                data:    make(map[string]interface{}),
+                logger:  logger,
+                expires: make(map[string]time.Time),
         }
+        for _, opt := range opts {
+                opt(mc)
+        }
+        return mc
 }
 
 // Set устанавливает значение для заданного ключа.
 func (mc *MemoryCache) Set(key string, value interface{}) error {
+        return mc.set(key, value, 0)
+}
+
+// SetWithTTL устанавливает значение, которое перестаёт возвращаться через ttl.
+// Просроченные записи удаляются из памяти при последующих вызовах SetWithTTL.
+func (mc *MemoryCache) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+        return mc.set(key, value, ttl)
+}
+
+// set сохраняет значение; ttl <= 0 означает бессрочную запись.
+func (mc *MemoryCache) set(key string, value interface{}, ttl time.Duration) error {
+        if mc.codec != nil {
+                encoded, err := mc.codec.Encode(value)
+                if err != nil {
+                        mc.logger.Error("Cache encode failed", core.Field{"key", key}, core.Field{"error", err})
+                        return err
+                }
+                value = encoded
+        }
         mc.mu.Lock()
         defer mc.mu.Unlock()
         mc.data[key] = value
+        delete(mc.expires, key)
+        if ttl > 0 {
+                now := time.Now()
+                mc.expires[key] = now.Add(ttl)
+                mc.sweep(now, ttl)
+        }
         mc.logger.Info("Cache set", core.Field{"key", key})
         return nil
 }
 
+// sweep удаляет просроченные записи не чаще одного раза за interval. Вызывается под mc.mu.
+func (mc *MemoryCache) sweep(now time.Time, interval time.Duration) {
+        if now.Before(mc.nextSweep) {
+                return
+        }
+        mc.nextSweep = now.Add(interval)
+        for key, expiresAt := range mc.expires {
+                if !now.Before(expiresAt) {
+                        delete(mc.data, key)
+                        delete(mc.expires, key)
+                }
+        }
+}
+
+// expired сообщает, истёк ли срок жизни записи. Вызывается под mc.mu.
+func (mc *MemoryCache) expired(key string) bool {
+        expiresAt, ok := mc.expires[key]
+        return ok && !time.Now().Before(expiresAt)
+}
+
 // Get возвращает значение по ключу. Если ключ отсутствует, возвращает ошибку.
 func (mc *MemoryCache) Get(key string) (interface{}, error) {
         mc.mu.RLock()
         defer mc.mu.RUnlock()
         val, exists := mc.data[key]
-        if !exists {
+        if !exists || mc.expired(key) {
                 mc.logger.Warn("Cache miss", core.Field{"key", key})
                 return nil, errors.New("key not found")
         }
@@ -55,11 +128,75 @@ func (mc *MemoryCache) Get(key string) (interface{}, error) {
 func (mc *MemoryCache) Delete(key string) error {
         mc.mu.Lock()
         defer mc.mu.Unlock()
-        if _, exists := mc.data[key]; !exists {
+        _, exists := mc.data[key]
+        expired := mc.expired(key)
+        delete(mc.data, key)
+        delete(mc.expires, key)
+        if !exists || expired {
                 mc.logger.Warn("Cache delete: key not found", core.Field{"key", key})
                 return errors.New("key not found")
         }
-        delete(mc.data, key)
         mc.logger.Info("Cache deleted", core.Field{"key", key})
         return nil
 }
+
+// Load декодирует значение по ключу в v с помощью кодека, заданного опцией WithCodec.
+func (mc *MemoryCache) Load(key string, v interface{}) error {
+        if mc.codec == nil {
+                return ErrNoCodec
+        }
+        val, err := mc.Get(key)
+        if err != nil {
+                return err
+        }
+        return mc.codec.Decode(val.([]byte), v)
+}
+
+// Loader реализуется кэшами, умеющими декодировать сохранённые значения (см. MemoryCache.Load).
+type Loader interface {
+        Load(key string, v interface{}) error
+}
+
+// LoadValue читает значение по ключу в v, который должен быть ненулевым указателем.
+// Кэши с кодеком (см. WithCodec) читаются через Load, остальные – через Get: значение
+// присваивается *v, если его тип совместим с типом *v. Возвращает false при промахе,
+// ошибке кэша или несовпадении типа.
+func LoadValue(c Cache, key string, v interface{}) bool {
+        if loader, ok := c.(Loader); ok {
+                err := loader.Load(key, v)
+                if err == nil {
+                        return true
+                }
+                if !errors.Is(err, ErrNoCodec) {
+                        return false
+                }
+        }
+        value, err := c.Get(key)
+        if err != nil {
+                return false
+        }
+        target := reflect.ValueOf(v)
+        if target.Kind() != reflect.Ptr || target.IsNil() || value == nil {
+                return false
+        }
+        source := reflect.ValueOf(value)
+        if !source.Type().AssignableTo(target.Elem().Type()) {
+                return false
+        }
+        target.Elem().Set(source)
+        return true
+}
+
+// Expirer реализуется кэшами, поддерживающими срок жизни записей (см. MemoryCache.SetWithTTL).
+type Expirer interface {
+        SetWithTTL(key string, value interface{}, ttl time.Duration) error
+}
+
+// SetWithTTL сохраняет значение со сроком жизни ttl, если кэш реализует Expirer,
+// и бессрочно через Set в противном случае.
+func SetWithTTL(c Cache, key string, value interface{}, ttl time.Duration) error {
+        if expirer, ok := c.(Expirer); ok {
+                return expirer.SetWithTTL(key, value, ttl)
+        }
+        return c.Set(key, value)
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+type profile struct {
+	Name  string
+	Roles []string
+}
+
+func TestMemoryCacheWithCodec(t *testing.T) {
+	for name, codec := range map[string]Codec{"json": JSONCodec{}, "gob": GobCodec{}} {
+		t.Run(name, func(t *testing.T) {
+			c := NewMemoryCache(core.NewLogger(core.FatalLevel), WithCodec(codec))
+			original := profile{Name: "alice", Roles: []string{"admin"}}
+			if err := c.Set("user:1", original); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			original.Roles[0] = "changed"
+
+			var loaded profile
+			if err := c.Load("user:1", &loaded); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if loaded.Name != "alice" || len(loaded.Roles) != 1 || loaded.Roles[0] != "admin" {
+				t.Errorf("unexpected value: %+v", loaded)
+			}
+		})
+	}
+}
+
+func TestMemoryCacheLoadWithoutCodec(t *testing.T) {
+	c := NewMemoryCache(core.NewLogger(core.FatalLevel))
+	var v int
+	if err := c.Load("k", &v); err != ErrNoCodec {
+		t.Errorf("got %v, want ErrNoCodec", err)
+	}
+}
+
+func TestMemoryCacheSetWithTTL(t *testing.T) {
+	c := NewMemoryCache(core.NewLogger(core.FatalLevel))
+	if err := SetWithTTL(c, "short", 1, 10*time.Millisecond); err != nil {
+		t.Fatalf("SetWithTTL: %v", err)
+	}
+	if err := c.Set("forever", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := c.Get("short"); err != nil {
+		t.Fatalf("Get before expiry: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := c.Get("short"); err == nil {
+		t.Error("expired key still returned")
+	}
+	// Следующая запись с TTL удаляет просроченные записи из памяти.
+	if err := c.SetWithTTL("other", 3, time.Minute); err != nil {
+		t.Fatalf("SetWithTTL: %v", err)
+	}
+	if _, ok := c.data["short"]; ok {
+		t.Error("expired key not swept")
+	}
+	if _, err := c.Get("forever"); err != nil {
+		t.Errorf("key without TTL expired: %v", err)
+	}
+}
+
+func TestLoadValue(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	for name, c := range map[string]*MemoryCache{
+		"plain": NewMemoryCache(logger),
+		"codec": NewMemoryCache(logger, WithCodec(JSONCodec{})),
+	} {
+		t.Run(name, func(t *testing.T) {
+			c.Set("n", 7)
+			var n int
+			if !LoadValue(c, "n", &n) || n != 7 {
+				t.Errorf("LoadValue = %d, want 7", n)
+			}
+			var missing int
+			if LoadValue(c, "missing", &missing) {
+				t.Error("LoadValue reported a hit for a missing key")
+			}
+		})
+	}
+	plain := NewMemoryCache(logger)
+	plain.Set("n", 7)
+	var wrongType string
+	if LoadValue(plain, "n", &wrongType) {
+		t.Error("LoadValue accepted a value of another type")
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec сериализует значения кэша. Используется бэкендами, хранящими байты,
+// а также MemoryCache при включённой опции WithCodec.
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte, v interface{}) error
+}
+
+// JSONCodec сериализует значения в JSON: данные удобно читать при отладке,
+// но неэкспортируемые поля и типы интерфейсов не сохраняются.
+type JSONCodec struct{}
+
+// Encode реализует Codec.
+func (JSONCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode реализует Codec.
+func (JSONCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec сериализует значения с помощью encoding/gob: компактнее и быстрее JSON
+// для Go-типов. Конкретные типы, хранимые в интерфейсах, нужно зарегистрировать через gob.Register.
+type GobCodec struct{}
+
+// Encode реализует Codec.
+func (GobCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode реализует Codec.
+func (GobCodec) Decode(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError – ошибка, возвращённая Bot API. Методы botClient возвращают её для ответов
+// с ok = false или кодом HTTP, отличным от 200, поэтому вызывающий код может различать
+// причины через errors.As:
+//
+//	var apiErr *core.APIError
+//	if errors.As(err, &apiErr) && apiErr.ErrorCode == http.StatusForbidden {
+//		// пользователь заблокировал бота
+//	}
+type APIError struct {
+	// Method – имя метода Bot API, например "sendMessage".
+	Method string
+	// ErrorCode – код ошибки Telegram (error_code); совпадает с кодом HTTP-ответа.
+	ErrorCode int
+	// Description – описание ошибки от Telegram.
+	Description string
+	// RetryAfter – через сколько секунд можно повторить запрос после ошибки 429.
+	RetryAfter int
+	// MigrateToChatID – новый ID чата, если группа была преобразована в супергруппу.
+	MigrateToChatID int64
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s failed with error %d: %s", e.Method, e.ErrorCode, e.Description)
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %ds)", e.RetryAfter)
+	}
+	return msg
+}
+
+// newAPIError строит APIError из тела ответа Bot API. Если тело не содержит ошибки
+// в формате Telegram (например, ответ прокси), используются HTTP-статус и текст тела.
+func newAPIError(method string, statusCode int, body []byte) *APIError {
+	var result struct {
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+		Parameters  struct {
+			RetryAfter      int   `json:"retry_after"`
+			MigrateToChatID int64 `json:"migrate_to_chat_id"`
+		} `json:"parameters"`
+	}
+	apiErr := &APIError{Method: method}
+	if json.Unmarshal(body, &result) == nil && result.ErrorCode != 0 {
+		apiErr.ErrorCode = result.ErrorCode
+		apiErr.Description = result.Description
+		apiErr.RetryAfter = result.Parameters.RetryAfter
+		apiErr.MigrateToChatID = result.Parameters.MigrateToChatID
+		return apiErr
+	}
+	apiErr.ErrorCode = statusCode
+	apiErr.Description = strings.TrimSpace(string(body))
+	if apiErr.Description == "" {
+		apiErr.Description = http.StatusText(statusCode)
+	}
+	return apiErr
+}
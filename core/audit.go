@@ -0,0 +1,75 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// AuditSink сохраняет исходный JSON каждого полученного обновления. Поллер (WithAuditSink)
+// и вебхук (webhooks.WithAuditSink) вызывают Record до маршрутизации обновления.
+// Ошибка записи логируется и не прерывает обработку обновления.
+type AuditSink interface {
+	Record(ctx context.Context, raw json.RawMessage) error
+}
+
+// FileAuditSink – AuditSink, дописывающий обновления в файл в формате JSON Lines:
+// одно обновление на строку. Такой файл можно воспроизвести функцией Replay.
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditSink открывает (или создаёт) файл path для дописывания обновлений.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileAuditSink{file: file}, nil
+}
+
+// Record записывает raw одной строкой в конец файла.
+func (s *FileAuditSink) Record(ctx context.Context, raw json.RawMessage) error {
+	var line bytes.Buffer
+	if err := json.Compact(&line, raw); err != nil {
+		return err
+	}
+	line.WriteByte('\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.file.Write(line.Bytes())
+	return err
+}
+
+// Sync сбрасывает записанные данные на диск.
+func (s *FileAuditSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Sync()
+}
+
+// Close закрывает файл. Его удобно регистрировать в Lifecycle через RegisterCloser.
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// auditUpdate передаёт обновление в sink. Для обновлений без исходного JSON
+// (созданных вручную) записывается их сериализованное представление.
+func auditUpdate(sink AuditSink, update Update, logger Logger) {
+	raw := update.Raw()
+	if raw == nil {
+		var err error
+		if raw, err = json.Marshal(update); err != nil {
+			logger.Error("Failed to marshal update for audit", Field{"update_id", update.UpdateID}, Field{"error", err})
+			return
+		}
+	}
+	if err := sink.Record(update.Context(), raw); err != nil {
+		logger.Error("Failed to record update for audit", Field{"update_id", update.UpdateID}, Field{"error", err})
+	}
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileAuditSinkWritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileAuditSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var update Update
+	if err := json.Unmarshal([]byte("{\n  \"update_id\": 1,\n  \"message\": {\"message_id\": 5, \"chat\": {\"id\": 2}}\n}"), &update); err != nil {
+		t.Fatal(err)
+	}
+	auditUpdate(sink, update, newTestLogger())
+	auditUpdate(sink, Update{UpdateID: 2}, newTestLogger())
+	if err := sink.Record(context.Background(), json.RawMessage(`{`)); err == nil {
+		t.Error("invalid JSON was recorded")
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"update_id":1,"message":{"message_id":5,"chat":{"id":2}}}` + "\n" + `{"update_id":2}` + "\n"
+	if string(data) != want {
+		t.Errorf("audit file:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -4,267 +4,401 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // Расширенный интерфейс BotAPI с дополнительными методами.
 type BotAPI interface {
-	SendMessage(ctx context.Context, chatID int64, text string) error
-	SendMessageWithMarkup(ctx context.Context, chatID int64, text string, replyMarkup interface{}) error
+	SendMessage(ctx context.Context, chatID ChatID, text string, opts ...SendOption) error
+	// SendMessageReturning отправляет текстовое сообщение и возвращает отправленное сообщение.
+	SendMessageReturning(ctx context.Context, chatID ChatID, text string, opts ...SendOption) (Message, error)
+	SendMessageWithMarkup(ctx context.Context, chatID ChatID, text string, replyMarkup interface{}, opts ...SendOption) error
 	GetUpdates(ctx context.Context, offset, limit, timeout int) ([]Update, error)
-	SendPhoto(ctx context.Context, chatID int64, photo interface{}, caption string, replyMarkup interface{}) error
-	SendDocument(ctx context.Context, chatID int64, document interface{}, caption string, replyMarkup interface{}) error
-	EditMessageText(ctx context.Context, chatID int64, messageID int, text string, replyMarkup interface{}) error
-	EditMessageReplyMarkup(ctx context.Context, chatID int64, messageID int, replyMarkup interface{}) error
+	SendPhoto(ctx context.Context, chatID ChatID, photo interface{}, caption string, replyMarkup interface{}, opts ...SendOption) error
+	SendDocument(ctx context.Context, chatID ChatID, document interface{}, caption string, replyMarkup interface{}, opts ...SendOption) error
+	// SendAnimation отправляет анимацию (GIF или H.264/MPEG-4 без звука).
+	SendAnimation(ctx context.Context, chatID ChatID, animation interface{}, caption string, replyMarkup interface{}, opts ...SendOption) error
+	EditMessageText(ctx context.Context, chatID ChatID, messageID int, text string, replyMarkup interface{}, opts ...SendOption) error
+	EditMessageReplyMarkup(ctx context.Context, chatID ChatID, messageID int, replyMarkup interface{}) error
 	AnswerCallbackQuery(ctx context.Context, callbackQueryID string, text string, showAlert bool) error
-	ForwardMessage(ctx context.Context, chatID int64, fromChatID int64, messageID int) error
-	GetChat(ctx context.Context, chatID int64) (Chat, error)
-	GetChatMembersCount(ctx context.Context, chatID int64) (int, error)
-	GetChatAdministrators(ctx context.Context, chatID int64) ([]Chat, error)
+	// AnswerAndEdit отвечает на callback-запрос и заменяет текст и клавиатуру сообщения,
+	// к которому была прикреплена нажатая кнопка.
+	AnswerAndEdit(ctx context.Context, cb CallbackQuery, text string, replyMarkup interface{}, opts ...SendOption) error
+	// ForwardMessage пересылает сообщение; тему форума задаёт опция WithMessageThreadID.
+	ForwardMessage(ctx context.Context, chatID, fromChatID ChatID, messageID int, opts ...SendOption) error
+	// CopyMessage копирует сообщение без ссылки на оригинал и возвращает идентификатор копии.
+	CopyMessage(ctx context.Context, chatID, fromChatID ChatID, messageID int, opts ...SendOption) (int, error)
+	GetChat(ctx context.Context, chatID ChatID) (Chat, error)
+	GetChatMembersCount(ctx context.Context, chatID ChatID) (int, error)
+	GetChatAdministrators(ctx context.Context, chatID ChatID) ([]Chat, error)
 	GetMe(ctx context.Context) (User, error)
+	// Username возвращает имя пользователя бота (без "@"), используя кэш GetMe.
+	Username(ctx context.Context) (string, error)
+	// InvalidateMe сбрасывает кэшированный результат GetMe.
+	InvalidateMe()
+	// SendMediaGroup отправляет альбом и возвращает созданные сообщения.
+	SendMediaGroup(ctx context.Context, chatID ChatID, media []InputMedia, opts ...SendOption) ([]Message, error)
+	// SendPaidMedia отправляет платные фото/видео, доступные за starCount Telegram Stars.
+	SendPaidMedia(ctx context.Context, chatID ChatID, starCount int, media []InputMedia, opts ...SendOption) error
+	// SendLongMessage отправляет текст любой длины, разбивая его на сообщения не длиннее MaxMessageLength.
+	SendLongMessage(ctx context.Context, chatID ChatID, text string, opts ...SendOption) ([]Message, error)
+	// CreateForumTopic создаёт тему форума; EditForumTopic, CloseForumTopic, ReopenForumTopic
+	// и DeleteForumTopic управляют существующей темой по её message_thread_id.
+	CreateForumTopic(ctx context.Context, chatID ChatID, name string, opts ...ForumTopicOption) (ForumTopic, error)
+	EditForumTopic(ctx context.Context, chatID ChatID, messageThreadID int, name string, opts ...ForumTopicOption) error
+	CloseForumTopic(ctx context.Context, chatID ChatID, messageThreadID int) error
+	ReopenForumTopic(ctx context.Context, chatID ChatID, messageThreadID int) error
+	DeleteForumTopic(ctx context.Context, chatID ChatID, messageThreadID int) error
+	// SetMyCommands задаёт список команд, показываемый в меню бота.
+	SetMyCommands(ctx context.Context, commands []BotCommand) error
+	// SetMessageReaction ставит на сообщение реакцию-эмодзи; пустая строка снимает реакцию бота.
+	SetMessageReaction(ctx context.Context, chatID ChatID, messageID int, emoji string) error
+	// GetCustomEmojiStickers возвращает стикеры кастомных эмодзи по их идентификаторам (не более 200).
+	GetCustomEmojiStickers(ctx context.Context, customEmojiIDs []string) ([]Sticker, error)
+	// SetPassportDataErrors сообщает пользователю об ошибках в переданных данных Telegram Passport.
+	SetPassportDataErrors(ctx context.Context, userID int64, errors []PassportElementError) error
+	// ApproveChatJoinRequest одобряет заявку пользователя на вступление в чат.
+	ApproveChatJoinRequest(ctx context.Context, chatID ChatID, userID int64) error
+	// DeclineChatJoinRequest отклоняет заявку пользователя на вступление в чат.
+	DeclineChatJoinRequest(ctx context.Context, chatID ChatID, userID int64) error
+	// SendMessageHumanized показывает статус «печатает…» в течение времени, пропорционального
+	// длине текста (не дольше нескольких секунд), после чего отправляет сообщение.
+	SendMessageHumanized(ctx context.Context, chatID ChatID, text string, opts ...SendOption) error
+	// SendChatAction показывает в чате статус действия бота, например «печатает…».
+	SendChatAction(ctx context.Context, chatID ChatID, action string) error
+	// DeleteMessage удаляет сообщение.
+	DeleteMessage(ctx context.Context, chatID ChatID, messageID int) error
+	// SendSelfDestructing отправляет сообщение и удаляет его через ttl.
+	SendSelfDestructing(ctx context.Context, chatID ChatID, text string, ttl time.Duration, opts ...SendOption) error
+	// SendGame отправляет игру с коротким именем gameShortName, заданным в @BotFather.
+	SendGame(ctx context.Context, chatID ChatID, gameShortName string, opts ...SendOption) (Message, error)
+	// SetGameScore устанавливает счёт пользователя в игре. Если force равен false,
+	// счёт меняется только при увеличении.
+	SetGameScore(ctx context.Context, userID int64, score int, ref GameMessageRef, force bool) error
+	// GetGameHighScores возвращает таблицу рекордов для пользователя и его соседей по таблице.
+	GetGameHighScores(ctx context.Context, userID int64, ref GameMessageRef) ([]GameHighScore, error)
+	// Broadcast отправляет одно и то же сообщение в несколько чатов и возвращает результат по каждому чату.
+	Broadcast(ctx context.Context, chatIDs []ChatID, text string, opts ...SendOption) []BroadcastResult
 	// Другие методы можно добавить при необходимости.
 }
 
+// BroadcastResult – результат отправки сообщения в один из чатов рассылки.
+// MessageID заполняется при успешной отправке и может использоваться для последующего редактирования.
+type BroadcastResult struct {
+	ChatID    ChatID
+	MessageID int
+	Err       error
+}
+
 // botClient – реализация интерфейса BotAPI.
 type botClient struct {
 	token      string
 	apiURL     string
 	httpClient *http.Client
 	logger     Logger
+	// defaultParseMode подставляется в отправки, для которых parse_mode не задан.
+	defaultParseMode string
+
+	// maxResponseSize ограничивает размер читаемого тела ответа.
+	maxResponseSize int64
+
+	// maxRetries и retryBaseDelay управляют повторами при временных сбоях (WithRetry).
+	maxRetries     int
+	retryBaseDelay time.Duration
+
+	// meMu защищает кэшированный результат getMe.
+	meMu sync.Mutex
+	me   *User
+
+	// deletesMu защищает запланированные удаления сообщений SendSelfDestructing.
+	deletesMu sync.Mutex
+	deletes   map[*pendingDelete]struct{}
+}
+
+// ClientOption задаёт необязательные параметры клиента Bot API.
+type ClientOption func(*botClient)
+
+// WithDefaultParseMode задаёт режим разметки ("HTML", "MarkdownV2"), применяемый ко всем
+// отправкам текста и подписей, в которых parse_mode не указан явно.
+func WithDefaultParseMode(mode string) ClientOption {
+	return func(b *botClient) {
+		b.defaultParseMode = mode
+	}
+}
+
+// WithMaxResponseSize задаёт максимальный размер тела ответа Bot API в байтах
+// (по умолчанию DefaultMaxResponseSize). Значение <= 0 снимает ограничение.
+func WithMaxResponseSize(n int64) ClientOption {
+	return func(b *botClient) {
+		b.maxResponseSize = n
+	}
+}
+
+// WithBaseURL задаёт адрес Bot API, например собственного (self-hosted) сервера
+// или тестового сервера testutil.FakeTelegram. По умолчанию – https://api.telegram.org.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(b *botClient) {
+		b.apiURL = fmt.Sprintf("%s/bot%s", strings.TrimSuffix(baseURL, "/"), b.token)
+	}
 }
 
 // NewBotClient возвращает новый экземпляр BotAPI, инициализированный токеном, логгером и HTTP-клиентом.
-func NewBotClient(token string, logger Logger, httpClient *http.Client) BotAPI {
+// Дополнительные параметры клиента передаются через opts.
+func NewBotClient(token string, logger Logger, httpClient *http.Client, opts ...ClientOption) BotAPI {
 	if httpClient == nil {
 		httpClient = &http.Client{}
 	}
-	return &botClient{
+	b := &botClient{
 		token:      token,
 		apiURL:     fmt.Sprintf("https://api.telegram.org/bot%s", token),
 		httpClient: httpClient,
 		logger:     logger,
+
+		maxResponseSize: DefaultMaxResponseSize,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.wrapRetry()
+	return b
+}
+
+// isNilMarkup сообщает, что разметка не задана: nil или nil-указатель/map/срез.
+func isNilMarkup(markup interface{}) bool {
+	if markup == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(markup); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// applyDefaults дополняет payload значениями по умолчанию клиента.
+// Явно заданные в payload параметры не перезаписываются.
+func (b *botClient) applyDefaults(payload map[string]interface{}) {
+	if _, ok := payload["parse_mode"]; !ok && b.defaultParseMode != "" {
+		payload["parse_mode"] = b.defaultParseMode
 	}
 }
 
 // SendMessage отправляет текстовое сообщение в указанный чат.
-func (b *botClient) SendMessage(ctx context.Context, chatID int64, text string) error {
-	endpoint := fmt.Sprintf("%s/sendMessage", b.apiURL)
+// Результат запроса не декодируется; отправленное сообщение возвращает SendMessageReturning.
+func (b *botClient) SendMessage(ctx context.Context, chatID ChatID, text string, opts ...SendOption) error {
+	return b.sendText(ctx, chatID, text, opts, nil)
+}
+
+// SendMessageReturning отправляет сообщение как SendMessage и возвращает его в виде,
+// сохранённом Telegram: message_id нужен, например, для последующего EditMessageText.
+func (b *botClient) SendMessageReturning(ctx context.Context, chatID ChatID, text string, opts ...SendOption) (Message, error) {
+	var msg Message
+	if err := b.sendText(ctx, chatID, text, opts, &msg); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+// sendText выполняет sendMessage для чата chat. Если out не nil, в него декодируется
+// отправленное сообщение.
+func (b *botClient) sendText(ctx context.Context, chat ChatID, text string, opts []SendOption, out *Message) error {
+	if chat.IsZero() {
+		return fmt.Errorf("sendMessage: chat id is empty")
+	}
 	payload := map[string]interface{}{
-		"chat_id": chatID,
+		"chat_id": chat,
 		"text":    text,
 	}
-	body, err := json.Marshal(payload)
+	options, err := b.sendOptions("sendMessage", nil, opts)
 	if err != nil {
-		b.logger.Error("Failed to marshal sendMessage payload", Field{"error", err})
 		return err
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		b.logger.Error("Failed to create sendMessage request", Field{"error", err})
-		return err
+	options.apply(payload)
+	b.applyDefaults(payload)
+	var result interface{}
+	if out != nil {
+		result = out
 	}
-	req.Header.Set("Content-Type", "application/json")
-	var resp *http.Response
-	WithRecovery(b.logger, func() {
-		resp, err = b.httpClient.Do(req)
-	})
-	if err != nil {
-		b.logger.Error("Error sending message", Field{"error", err})
+	if err := b.call(ctx, "sendMessage", payload, result); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		b.logger.Error("Non-OK response from sendMessage", Field{"status", resp.Status}, Field{"body", string(respBody)})
-		return fmt.Errorf("sendMessage failed with status: %s", resp.Status)
-	}
-	b.logger.Info("Message sent successfully", Field{"chat_id", chatID}, Field{"text", text})
+	b.logger.Info("Message sent successfully", Field{"chat_id", chat.String()}, Field{"text", text})
 	return nil
 }
 
 // SendMessageWithMarkup отправляет сообщение с дополнительной разметкой (например, inline-клавиатурой).
-func (b *botClient) SendMessageWithMarkup(ctx context.Context, chatID int64, text string, replyMarkup interface{}) error {
-	endpoint := fmt.Sprintf("%s/sendMessage", b.apiURL)
-	payload := map[string]interface{}{
-		"chat_id":      chatID,
-		"text":         text,
-		"reply_markup": replyMarkup,
-	}
-	body, err := json.Marshal(payload)
+func (b *botClient) SendMessageWithMarkup(ctx context.Context, chatID ChatID, text string, replyMarkup interface{}, opts ...SendOption) error {
+	options, err := b.sendOptions("sendMessage", replyMarkup, opts)
 	if err != nil {
-		b.logger.Error("Failed to marshal sendMessageWithMarkup payload", Field{"error", err})
 		return err
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		b.logger.Error("Failed to create sendMessageWithMarkup request", Field{"error", err})
-		return err
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
 	}
-	req.Header.Set("Content-Type", "application/json")
-	var resp *http.Response
-	WithRecovery(b.logger, func() {
-		resp, err = b.httpClient.Do(req)
-	})
-	if err != nil {
-		b.logger.Error("Error sending message with markup", Field{"error", err})
+	options.apply(payload)
+	b.applyDefaults(payload)
+	if err := b.call(ctx, "sendMessage", payload, nil); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		b.logger.Error("Non-OK response from sendMessageWithMarkup", Field{"status", resp.Status}, Field{"body", string(respBody)})
-		return fmt.Errorf("sendMessageWithMarkup failed with status: %s", resp.Status)
-	}
 	b.logger.Info("Message with markup sent successfully", Field{"chat_id", chatID}, Field{"text", text})
 	return nil
 }
 
+// Допустимые границы параметра limit метода getUpdates.
+const (
+	minUpdatesLimit = 1
+	maxUpdatesLimit = 100
+)
+
+// pollDeadlineMargin – запас времени на передачу ответа getUpdates до истечения контекста.
+const pollDeadlineMargin = time.Second
+
+// fitPollTimeout уменьшает timeout long polling (в секундах) так, чтобы Telegram ответил
+// до истечения дедлайна ctx, а не запрос был отменён на середине ожидания.
+func fitPollTimeout(ctx context.Context, timeout int) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout
+	}
+	available := int((time.Until(deadline) - pollDeadlineMargin) / time.Second)
+	if available < 0 {
+		available = 0
+	}
+	if available < timeout {
+		return available
+	}
+	return timeout
+}
+
 // GetUpdates получает обновления от Telegram API с использованием контекста.
+// limit приводится к диапазону [1, 100], отрицательный timeout заменяется на 0;
+// о каждой корректировке пишется предупреждение в лог.
+// Если дедлайн ctx наступает раньше, чем истечёт timeout, timeout сокращается под дедлайн.
 func (b *botClient) GetUpdates(ctx context.Context, offset, limit, timeout int) ([]Update, error) {
-	endpoint := fmt.Sprintf("%s/getUpdates", b.apiURL)
+	if limit < minUpdatesLimit || limit > maxUpdatesLimit {
+		adjusted := limit
+		if adjusted < minUpdatesLimit {
+			adjusted = minUpdatesLimit
+		} else {
+			adjusted = maxUpdatesLimit
+		}
+		b.logger.Warn("getUpdates limit out of range, adjusting", Field{"requested", limit}, Field{"adjusted", adjusted})
+		limit = adjusted
+	}
+	if timeout < 0 {
+		b.logger.Warn("getUpdates timeout is negative, using 0", Field{"requested", timeout})
+		timeout = 0
+	}
+	if fitted := fitPollTimeout(ctx, timeout); fitted != timeout {
+		b.logger.Debug("getUpdates timeout shortened to fit context deadline", Field{"requested", timeout}, Field{"adjusted", fitted})
+		timeout = fitted
+	}
 	params := url.Values{}
 	if offset > 0 {
 		params.Set("offset", strconv.Itoa(offset))
 	}
 	params.Set("limit", strconv.Itoa(limit))
 	params.Set("timeout", strconv.Itoa(timeout))
-	reqURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
-	if err != nil {
-		b.logger.Error("Failed to create getUpdates request", Field{"error", err})
-		return nil, err
-	}
-	var resp *http.Response
-	WithRecovery(b.logger, func() {
-		resp, err = b.httpClient.Do(req)
-	})
-	if err != nil {
-		b.logger.Error("Error executing getUpdates request", Field{"error", err})
+	var updates []Update
+	if err := b.get(ctx, "getUpdates", params, &updates); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		b.logger.Error("Non-OK response from getUpdates", Field{"status", resp.Status}, Field{"body", string(respBody)})
-		return nil, fmt.Errorf("getUpdates failed with status: %s", resp.Status)
-	}
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		b.logger.Error("Error reading getUpdates response", Field{"error", err})
-		return nil, err
-	}
-	var result struct {
-		OK     bool     `json:"ok"`
-		Result []Update `json:"result"`
-	}
-	if err = json.Unmarshal(respBody, &result); err != nil {
-		b.logger.Error("Error unmarshalling getUpdates response", Field{"error", err})
-		return nil, err
-	}
-	if !result.OK {
-		b.logger.Error("Telegram API returned not OK for getUpdates", Field{"response", string(respBody)})
-		return nil, fmt.Errorf("telegram API error: %s", string(respBody))
-	}
-	b.logger.Info("Fetched updates", Field{"updates_count", len(result.Result)})
-	return result.Result, nil
+	b.logger.Info("Fetched updates", Field{"updates_count", len(updates)})
+	return updates, nil
 }
 
 // SendPhoto отправляет фото в указанный чат.
-// Параметр photo может быть либо строкой (URL или file_id), либо файлом (но для файлов нужна дополнительная обработка).
-func (b *botClient) SendPhoto(ctx context.Context, chatID int64, photo interface{}, caption string, replyMarkup interface{}) error {
-	endpoint := fmt.Sprintf("%s/sendPhoto", b.apiURL)
+// Параметр photo – строка (URL или file_id) либо локальный файл: *os.File или FileUpload.
+// Файлы отправляются через multipart/form-data, строки – в JSON.
+// Дополнительные параметры (например, WithSpoiler) передаются через opts.
+func (b *botClient) SendPhoto(ctx context.Context, chatID ChatID, photo interface{}, caption string, replyMarkup interface{}, opts ...SendOption) error {
+	options, err := b.sendOptions("sendPhoto", replyMarkup, opts)
+	if err != nil {
+		return err
+	}
 	payload := map[string]interface{}{
 		"chat_id": chatID,
 		"photo":   photo,
 		"caption": caption,
 	}
-	if replyMarkup != nil {
-		payload["reply_markup"] = replyMarkup
-	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		b.logger.Error("Failed to marshal sendPhoto payload", Field{"error", err})
+	options.apply(payload)
+	b.applyDefaults(payload)
+	if err := b.sendMedia(ctx, "sendPhoto", "photo", payload); err != nil {
 		return err
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(body))
+	b.logger.Info("Photo sent successfully", Field{"chat_id", chatID})
+	return nil
+}
+
+// SendAnimation отправляет анимацию в указанный чат.
+// Параметр animation, как и в SendPhoto, – URL, file_id, *os.File или FileUpload. Ширина, высота и длительность задаются
+// опциями WithDimensions и WithDuration.
+func (b *botClient) SendAnimation(ctx context.Context, chatID ChatID, animation interface{}, caption string, replyMarkup interface{}, opts ...SendOption) error {
+	options, err := b.sendOptions("sendAnimation", replyMarkup, opts)
 	if err != nil {
-		b.logger.Error("Failed to create sendPhoto request", Field{"error", err})
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	var resp *http.Response
-	WithRecovery(b.logger, func() {
-		resp, err = b.httpClient.Do(req)
-	})
-	if err != nil {
-		b.logger.Error("Error sending photo", Field{"error", err})
-		return err
+	payload := map[string]interface{}{
+		"chat_id":   chatID,
+		"animation": animation,
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		b.logger.Error("Non-OK response from sendPhoto", Field{"status", resp.Status}, Field{"body", string(respBody)})
-		return fmt.Errorf("sendPhoto failed with status: %s", resp.Status)
+	if caption != "" {
+		payload["caption"] = caption
 	}
-	b.logger.Info("Photo sent successfully", Field{"chat_id", chatID})
+	options.apply(payload)
+	b.applyDefaults(payload)
+	if err := b.sendMedia(ctx, "sendAnimation", "animation", payload); err != nil {
+		return err
+	}
+	b.logger.Info("Animation sent successfully", Field{"chat_id", chatID})
 	return nil
 }
 
 // SendDocument отправляет документ в указанный чат.
-func (b *botClient) SendDocument(ctx context.Context, chatID int64, document interface{}, caption string, replyMarkup interface{}) error {
-	endpoint := fmt.Sprintf("%s/sendDocument", b.apiURL)
+// Параметр document, как и в SendPhoto, – URL, file_id, *os.File или FileUpload.
+func (b *botClient) SendDocument(ctx context.Context, chatID ChatID, document interface{}, caption string, replyMarkup interface{}, opts ...SendOption) error {
+	options, err := b.sendOptions("sendDocument", replyMarkup, opts)
+	if err != nil {
+		return err
+	}
 	payload := map[string]interface{}{
 		"chat_id":  chatID,
 		"document": document,
 		"caption":  caption,
 	}
-	if replyMarkup != nil {
-		payload["reply_markup"] = replyMarkup
-	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		b.logger.Error("Failed to marshal sendDocument payload", Field{"error", err})
-		return err
-	}
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		b.logger.Error("Failed to create sendDocument request", Field{"error", err})
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	var resp *http.Response
-	WithRecovery(b.logger, func() {
-		resp, err = b.httpClient.Do(req)
-	})
-	if err != nil {
-		b.logger.Error("Error sending document", Field{"error", err})
+	options.apply(payload)
+	b.applyDefaults(payload)
+	if err := b.sendMedia(ctx, "sendDocument", "document", payload); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		b.logger.Error("Non-OK response from sendDocument", Field{"status", resp.Status}, Field{"body", string(respBody)})
-		return fmt.Errorf("sendDocument failed with status: %s", resp.Status)
-	}
 	b.logger.Info("Document sent successfully", Field{"chat_id", chatID})
 	return nil
 }
 
 // EditMessageText редактирует текст ранее отправленного сообщения.
-func (b *botClient) EditMessageText(ctx context.Context, chatID int64, messageID int, text string, replyMarkup interface{}) error {
+func (b *botClient) EditMessageText(ctx context.Context, chatID ChatID, messageID int, text string, replyMarkup interface{}, opts ...SendOption) error {
+	options, err := b.sendOptions("editMessageText", replyMarkup, opts)
+	if err != nil {
+		return err
+	}
 	endpoint := fmt.Sprintf("%s/editMessageText", b.apiURL)
 	payload := map[string]interface{}{
 		"chat_id":    chatID,
 		"message_id": messageID,
 		"text":       text,
 	}
-	if replyMarkup != nil {
-		payload["reply_markup"] = replyMarkup
-	}
+	options.apply(payload)
+	b.applyDefaults(payload)
 	body, err := json.Marshal(payload)
 	if err != nil {
 		b.logger.Error("Failed to marshal editMessageText payload", Field{"error", err})
@@ -286,21 +420,28 @@ func (b *botClient) EditMessageText(ctx context.Context, chatID int64, messageID
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
+		respBody, _ := ReadLimited(resp.Body, b.maxResponseSize)
 		b.logger.Error("Non-OK response from editMessageText", Field{"status", resp.Status}, Field{"body", string(respBody)})
-		return fmt.Errorf("editMessageText failed with status: %s", resp.Status)
+		return newAPIError("editMessageText", resp.StatusCode, respBody)
 	}
 	b.logger.Info("Message text edited successfully", Field{"chat_id", chatID}, Field{"message_id", messageID})
 	return nil
 }
 
 // EditMessageReplyMarkup обновляет reply_markup для сообщения.
-func (b *botClient) EditMessageReplyMarkup(ctx context.Context, chatID int64, messageID int, replyMarkup interface{}) error {
+// Если replyMarkup равен nil (в том числе nil-указателю на клавиатуру), параметр reply_markup
+// не передаётся и Telegram удаляет inline-клавиатуру сообщения.
+func (b *botClient) EditMessageReplyMarkup(ctx context.Context, chatID ChatID, messageID int, replyMarkup interface{}) error {
+	if err := b.checkReplyMarkup("editMessageReplyMarkup", replyMarkup); err != nil {
+		return err
+	}
 	endpoint := fmt.Sprintf("%s/editMessageReplyMarkup", b.apiURL)
 	payload := map[string]interface{}{
-		"chat_id":     chatID,
-		"message_id":  messageID,
-		"reply_markup": replyMarkup,
+		"chat_id":    chatID,
+		"message_id": messageID,
+	}
+	if !isNilMarkup(replyMarkup) {
+		payload["reply_markup"] = replyMarkup
 	}
 
 	body, err := json.Marshal(payload)
@@ -326,17 +467,17 @@ func (b *botClient) EditMessageReplyMarkup(ctx context.Context, chatID int64, me
 	}
 	defer resp.Body.Close()
 
-	respBody, err := ioutil.ReadAll(resp.Body)
+	respBody, err := ReadLimited(resp.Body, b.maxResponseSize)
 	if err != nil {
 		b.logger.Error("Error reading editMessageReplyMarkup response", Field{"error", err})
 		return err
 	}
 	if resp.StatusCode != http.StatusOK {
 		b.logger.Error("Non-OK response from editMessageReplyMarkup",
-		 Field{"status", resp.Status},
+			Field{"status", resp.Status},
 			Field{"body", string(respBody)},
 		)
-		return fmt.Errorf("editMessageReplyMarkup failed with status: %s", resp.Status)
+		return newAPIError("editMessageReplyMarkup", resp.StatusCode, respBody)
 	}
 
 	b.logger.Info("Message reply markup edited successfully", Field{"chat_id", chatID}, Field{"message_id", messageID})
@@ -373,22 +514,63 @@ func (b *botClient) AnswerCallbackQuery(ctx context.Context, callbackQueryID str
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
+		respBody, _ := ReadLimited(resp.Body, b.maxResponseSize)
 		b.logger.Error("Non-OK response from answerCallbackQuery", Field{"status", resp.Status}, Field{"body", string(respBody)})
-		return fmt.Errorf("answerCallbackQuery failed with status: %s", resp.Status)
+		return newAPIError("answerCallbackQuery", resp.StatusCode, respBody)
 	}
 	b.logger.Info("Callback query answered successfully", Field{"callback_query_id", callbackQueryID})
 	return nil
 }
 
+// ErrNoCallbackMessage возвращается AnswerAndEdit, если в callback-запросе нет ни сообщения,
+// ни inline_message_id.
+var ErrNoCallbackMessage = errors.New("callback query has no message to edit")
+
+// AnswerAndEdit отвечает на callback-запрос cb без уведомления и редактирует исходное сообщение:
+// cb.Message или, для сообщений, отправленных в inline-режиме, cb.InlineMessageID.
+// Редактирование выполняется, даже если ответить на запрос не удалось; возвращается первая ошибка.
+// Некорректная разметка отклоняется до обоих запросов.
+func (b *botClient) AnswerAndEdit(ctx context.Context, cb CallbackQuery, text string, replyMarkup interface{}, opts ...SendOption) error {
+	options, err := b.sendOptions("editMessageText", replyMarkup, opts)
+	if err != nil {
+		return err
+	}
+	answerErr := b.AnswerCallbackQuery(ctx, cb.ID, "", false)
+
+	payload := map[string]interface{}{
+		"text": text,
+	}
+	switch {
+	case cb.Message != nil:
+		payload["chat_id"] = cb.Message.Chat.ID
+		payload["message_id"] = cb.Message.MessageID
+	case cb.InlineMessageID != "":
+		payload["inline_message_id"] = cb.InlineMessageID
+	default:
+		if answerErr != nil {
+			return answerErr
+		}
+		return ErrNoCallbackMessage
+	}
+	options.apply(payload)
+	b.applyDefaults(payload)
+	editErr := b.call(ctx, "editMessageText", payload, nil)
+	if answerErr != nil {
+		return answerErr
+	}
+	return editErr
+}
+
 // ForwardMessage пересылает сообщение из одного чата в другой.
-func (b *botClient) ForwardMessage(ctx context.Context, chatID int64, fromChatID int64, messageID int) error {
+// Опция WithMessageThreadID направляет сообщение в тему форума.
+func (b *botClient) ForwardMessage(ctx context.Context, chatID, fromChatID ChatID, messageID int, opts ...SendOption) error {
 	endpoint := fmt.Sprintf("%s/forwardMessage", b.apiURL)
 	payload := map[string]interface{}{
 		"chat_id":      chatID,
 		"from_chat_id": fromChatID,
 		"message_id":   messageID,
 	}
+	newSendOptions(opts).apply(payload)
 	body, err := json.Marshal(payload)
 	if err != nil {
 		b.logger.Error("Failed to marshal forwardMessage payload", Field{"error", err})
@@ -410,165 +592,504 @@ func (b *botClient) ForwardMessage(ctx context.Context, chatID int64, fromChatID
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := ioutil.ReadAll(resp.Body)
+		respBody, _ := ReadLimited(resp.Body, b.maxResponseSize)
 		b.logger.Error("Non-OK response from forwardMessage", Field{"status", resp.Status}, Field{"body", string(respBody)})
-		return fmt.Errorf("forwardMessage failed with status: %s", resp.Status)
+		return newAPIError("forwardMessage", resp.StatusCode, respBody)
 	}
 	b.logger.Info("Message forwarded successfully", Field{"chat_id", chatID}, Field{"from_chat_id", fromChatID}, Field{"message_id", messageID})
 	return nil
 }
 
+// CopyMessage копирует сообщение (copyMessage): в отличие от ForwardMessage, копия
+// не содержит ссылки на исходное сообщение. Опция WithMessageThreadID направляет копию
+// в тему форума. Возвращает идентификатор созданного сообщения.
+func (b *botClient) CopyMessage(ctx context.Context, chatID, fromChatID ChatID, messageID int, opts ...SendOption) (int, error) {
+	payload := map[string]interface{}{
+		"chat_id":      chatID,
+		"from_chat_id": fromChatID,
+		"message_id":   messageID,
+	}
+	newSendOptions(opts).apply(payload)
+	var result struct {
+		MessageID int `json:"message_id"`
+	}
+	if err := b.call(ctx, "copyMessage", payload, &result); err != nil {
+		return 0, err
+	}
+	b.logger.Info("Message copied successfully", Field{"chat_id", chatID}, Field{"from_chat_id", fromChatID}, Field{"message_id", messageID})
+	return result.MessageID, nil
+}
+
 // Пример реализации метода GetChat.
-func (b *botClient) GetChat(ctx context.Context, chatID int64) (Chat, error) {
-	endpoint := fmt.Sprintf("%s/getChat?chat_id=%d", b.apiURL, chatID)
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
-	if err != nil {
-		b.logger.Error("Failed to create getChat request", Field{"error", err})
-		return Chat{}, err
+func (b *botClient) GetChat(ctx context.Context, chat ChatID) (Chat, error) {
+	if chat.IsZero() {
+		return Chat{}, fmt.Errorf("getChat: chat id is empty")
 	}
-	var resp *http.Response
-	WithRecovery(b.logger, func() {
-		resp, err = b.httpClient.Do(req)
-	})
-	if err != nil {
-		b.logger.Error("Error executing getChat request", Field{"error", err})
+	var result Chat
+	if err := b.get(ctx, "getChat", url.Values{"chat_id": {chat.String()}}, &result); err != nil {
 		return Chat{}, err
 	}
-	defer resp.Body.Close()
+	b.logger.Info("Chat retrieved successfully", Field{"chat_id", chat.String()})
+	return result, nil
+}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		b.logger.Error("Error reading getChat response", Field{"error", err})
-		return Chat{}, err
+// Пример реализации метода GetChatMembersCount.
+func (b *botClient) GetChatMembersCount(ctx context.Context, chatID ChatID) (int, error) {
+	var count int
+	if err := b.get(ctx, "getChatMembersCount", url.Values{"chat_id": {chatID.String()}}, &count); err != nil {
+		return 0, err
 	}
-	var result struct {
-		OK     bool `json:"ok"`
-		Result Chat `json:"result"`
+	b.logger.Info("Chat members count retrieved", Field{"chat_id", chatID}, Field{"count", count})
+	return count, nil
+}
+
+// Пример реализации метода GetChatAdministrators.
+func (b *botClient) GetChatAdministrators(ctx context.Context, chatID ChatID) ([]Chat, error) {
+	var admins []Chat
+	if err := b.get(ctx, "getChatAdministrators", url.Values{"chat_id": {chatID.String()}}, &admins); err != nil {
+		return nil, err
 	}
-	if err = json.Unmarshal(bodyBytes, &result); err != nil {
-		b.logger.Error("Error unmarshalling getChat response", Field{"error", err})
-		return Chat{}, err
+	b.logger.Info("Chat administrators retrieved", Field{"chat_id", chatID}, Field{"count", len(admins)})
+	return admins, nil
+}
+
+// GetMe возвращает информацию о боте. Результат первого успешного вызова кэшируется,
+// так как данные бота не меняются во время работы; сбросить кэш можно через InvalidateMe.
+func (b *botClient) GetMe(ctx context.Context) (User, error) {
+	b.meMu.Lock()
+	defer b.meMu.Unlock()
+	if b.me != nil {
+		return *b.me, nil
 	}
-	if !result.OK {
-		b.logger.Error("Telegram API returned not OK for getChat", Field{"response", string(bodyBytes)})
-		return Chat{}, fmt.Errorf("getChat failed with response: %s", string(bodyBytes))
+	var me User
+	if err := b.get(ctx, "getMe", nil, &me); err != nil {
+		return User{}, err
 	}
-	b.logger.Info("Chat retrieved successfully", Field{"chat_id", chatID})
-	return result.Result, nil
+	b.logger.Info("GetMe executed successfully")
+	b.me = &me
+	return me, nil
 }
 
-// Пример реализации метода GetChatMembersCount.
-func (b *botClient) GetChatMembersCount(ctx context.Context, chatID int64) (int, error) {
-	endpoint := fmt.Sprintf("%s/getChatMembersCount?chat_id=%d", b.apiURL, chatID)
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+// Username возвращает имя пользователя бота без символа "@".
+func (b *botClient) Username(ctx context.Context) (string, error) {
+	me, err := b.GetMe(ctx)
 	if err != nil {
-		b.logger.Error("Failed to create getChatMembersCount request", Field{"error", err})
-		return 0, err
+		return "", err
 	}
-	var resp *http.Response
-	WithRecovery(b.logger, func() {
-		resp, err = b.httpClient.Do(req)
-	})
-	if err != nil {
-		b.logger.Error("Error executing getChatMembersCount request", Field{"error", err})
-		return 0, err
+	return me.Username, nil
+}
+
+// InvalidateMe сбрасывает кэш GetMe; следующий вызов снова обратится к Telegram.
+func (b *botClient) InvalidateMe() {
+	b.meMu.Lock()
+	defer b.meMu.Unlock()
+	b.me = nil
+}
+
+// SendMediaGroup отправляет группу фото/видео/документов одним альбомом.
+// Telegram возвращает по сообщению на каждый элемент альбома.
+// Альбом должен содержать от 2 до 10 элементов; иначе запрос не отправляется.
+// Параметры отправки (DisableNotification, ProtectContent) задаются через opts.
+func (b *botClient) SendMediaGroup(ctx context.Context, chatID ChatID, media []InputMedia, opts ...SendOption) ([]Message, error) {
+	if len(media) < 2 || len(media) > 10 {
+		b.logger.Error("Invalid media group size", Field{"chat_id", chatID}, Field{"count", len(media)})
+		return nil, fmt.Errorf("sendMediaGroup: media group must contain 2 to 10 items, got %d", len(media))
 	}
-	defer resp.Body.Close()
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"media":   media,
+	}
+	newSendOptions(opts).apply(payload)
+	var messages []Message
+	if err := b.call(ctx, "sendMediaGroup", payload, &messages); err != nil {
+		return nil, err
+	}
+	b.logger.Info("Media group sent successfully", Field{"chat_id", chatID}, Field{"count", len(messages)})
+	return messages, nil
+}
+
+// SendPaidMedia отправляет платное медиа (sendPaidMedia).
+// Элементы media должны иметь тип "photo" или "video"; подписи элементов не поддерживаются Telegram
+// и не передаются. Параметры сообщения (ParseMode, ReplyMarkup и т.д.) задаются через opts.
+func (b *botClient) SendPaidMedia(ctx context.Context, chatID ChatID, starCount int, media []InputMedia, opts ...SendOption) error {
+	if starCount <= 0 {
+		return fmt.Errorf("sendPaidMedia: star count must be positive, got %d", starCount)
+	}
+	if len(media) == 0 {
+		return fmt.Errorf("sendPaidMedia: media must not be empty")
+	}
+	items := make([]map[string]string, 0, len(media))
+	for _, m := range media {
+		items = append(items, map[string]string{
+			"type":  m.Type,
+			"media": m.Media,
+		})
+	}
+	payload := map[string]interface{}{
+		"chat_id":    chatID,
+		"star_count": starCount,
+		"media":      items,
+	}
+	newSendOptions(opts).apply(payload)
+	b.applyDefaults(payload)
+	if err := b.call(ctx, "sendPaidMedia", payload, nil); err != nil {
+		return err
+	}
+	b.logger.Info("Paid media sent successfully", Field{"chat_id", chatID}, Field{"star_count", starCount})
+	return nil
+}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+// SendLongMessage разбивает текст с помощью SplitTextWithEntities и отправляет части по порядку.
+// Сущности из WithEntities распределяются по частям со смещениями относительно начала каждой части.
+// Клавиатура из ReplyMarkup прикрепляется только к последнему сообщению.
+// При ошибке возвращаются уже отправленные сообщения и сама ошибка.
+// Разбиение не учитывает разметку ParseMode, поэтому каждая часть должна оставаться корректной сама по себе.
+func (b *botClient) SendLongMessage(ctx context.Context, chatID ChatID, text string, opts ...SendOption) ([]Message, error) {
+	options, err := b.sendOptions("sendMessage", nil, opts)
 	if err != nil {
-		b.logger.Error("Error reading getChatMembersCount response", Field{"error", err})
-		return 0, err
+		return nil, err
 	}
-	var result struct {
-		OK     bool `json:"ok"`
-		Result int  `json:"result"`
+	parts := SplitTextWithEntities(text, options.Entities, MaxMessageLength)
+	messages := make([]Message, 0, len(parts))
+	for i, part := range parts {
+		partOptions := options
+		partOptions.Entities = part.Entities
+		if i < len(parts)-1 {
+			partOptions.ReplyMarkup = nil
+		}
+		payload := map[string]interface{}{
+			"chat_id": chatID,
+			"text":    part.Text,
+		}
+		partOptions.apply(payload)
+		msg, err := b.sendMessage(ctx, payload)
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+	b.logger.Info("Long message sent successfully", Field{"chat_id", chatID}, Field{"parts", len(messages)})
+	return messages, nil
+}
+
+// Broadcast последовательно отправляет текст в каждый из чатов с одинаковыми параметрами opts.
+// Ошибка отправки в один чат не прерывает рассылку; при отмене контекста
+// оставшиеся чаты помечаются ошибкой контекста, а при некорректной разметке – ошибкой разметки.
+func (b *botClient) Broadcast(ctx context.Context, chatIDs []ChatID, text string, opts ...SendOption) []BroadcastResult {
+	options, optionsErr := b.sendOptions("sendMessage", nil, opts)
+	results := make([]BroadcastResult, 0, len(chatIDs))
+	for _, chatID := range chatIDs {
+		if optionsErr != nil {
+			results = append(results, BroadcastResult{ChatID: chatID, Err: optionsErr})
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			results = append(results, BroadcastResult{ChatID: chatID, Err: err})
+			continue
+		}
+		payload := map[string]interface{}{
+			"chat_id": chatID,
+			"text":    text,
+		}
+		options.apply(payload)
+		msg, err := b.sendMessage(ctx, payload)
+		results = append(results, BroadcastResult{ChatID: chatID, MessageID: msg.MessageID, Err: err})
+	}
+	b.logger.Info("Broadcast finished", Field{"chats_count", len(chatIDs)})
+	return results
+}
+
+// SetMyCommands задаёт список команд бота для меню Telegram (setMyCommands).
+func (b *botClient) SetMyCommands(ctx context.Context, commands []BotCommand) error {
+	if commands == nil {
+		commands = []BotCommand{}
 	}
-	if err = json.Unmarshal(bodyBytes, &result); err != nil {
-		b.logger.Error("Error unmarshalling getChatMembersCount response", Field{"error", err})
-		return 0, err
+	payload := map[string]interface{}{
+		"commands": commands,
 	}
-	if !result.OK {
-		b.logger.Error("Telegram API returned not OK for getChatMembersCount", Field{"response", string(bodyBytes)})
-		return 0, fmt.Errorf("getChatMembersCount failed with response: %s", string(bodyBytes))
+	if err := b.call(ctx, "setMyCommands", payload, nil); err != nil {
+		return err
 	}
-	b.logger.Info("Chat members count retrieved", Field{"chat_id", chatID}, Field{"count", result.Result})
-	return result.Result, nil
+	b.logger.Info("Bot commands set", Field{"count", len(commands)})
+	return nil
 }
 
-// Пример реализации метода GetChatAdministrators.
-func (b *botClient) GetChatAdministrators(ctx context.Context, chatID int64) ([]Chat, error) {
-	endpoint := fmt.Sprintf("%s/getChatAdministrators?chat_id=%d", b.apiURL, chatID)
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
-	if err != nil {
-		b.logger.Error("Failed to create getChatAdministrators request", Field{"error", err})
+// SetMessageReaction устанавливает реакцию бота на сообщение (setMessageReaction).
+func (b *botClient) SetMessageReaction(ctx context.Context, chatID ChatID, messageID int, emoji string) error {
+	reaction := []map[string]string{}
+	if emoji != "" {
+		reaction = append(reaction, map[string]string{"type": "emoji", "emoji": emoji})
+	}
+	payload := map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"reaction":   reaction,
+	}
+	if err := b.call(ctx, "setMessageReaction", payload, nil); err != nil {
+		return err
+	}
+	b.logger.Debug("Message reaction set", Field{"chat_id", chatID}, Field{"message_id", messageID})
+	return nil
+}
+
+// GetCustomEmojiStickers получает информацию о кастомных эмодзи (getCustomEmojiStickers).
+func (b *botClient) GetCustomEmojiStickers(ctx context.Context, customEmojiIDs []string) ([]Sticker, error) {
+	payload := map[string]interface{}{
+		"custom_emoji_ids": customEmojiIDs,
+	}
+	var stickers []Sticker
+	if err := b.call(ctx, "getCustomEmojiStickers", payload, &stickers); err != nil {
 		return nil, err
 	}
-	var resp *http.Response
-	WithRecovery(b.logger, func() {
-		resp, err = b.httpClient.Do(req)
-	})
-	if err != nil {
-		b.logger.Error("Error executing getChatAdministrators request", Field{"error", err})
+	return stickers, nil
+}
+
+// SetPassportDataErrors вызывает setPassportDataErrors. Пользователь не сможет повторно
+// отправить данные, пока ошибки не будут исправлены.
+func (b *botClient) SetPassportDataErrors(ctx context.Context, userID int64, elementErrors []PassportElementError) error {
+	payload := map[string]interface{}{
+		"user_id": userID,
+		"errors":  elementErrors,
+	}
+	if err := b.call(ctx, "setPassportDataErrors", payload, nil); err != nil {
+		return err
+	}
+	b.logger.Info("Passport data errors set", Field{"user_id", userID}, Field{"count", len(elementErrors)})
+	return nil
+}
+
+// ApproveChatJoinRequest вызывает approveChatJoinRequest. Бот должен быть администратором
+// чата с правом can_invite_users.
+func (b *botClient) ApproveChatJoinRequest(ctx context.Context, chatID ChatID, userID int64) error {
+	return b.answerChatJoinRequest(ctx, "approveChatJoinRequest", chatID, userID)
+}
+
+// DeclineChatJoinRequest вызывает declineChatJoinRequest.
+func (b *botClient) DeclineChatJoinRequest(ctx context.Context, chatID ChatID, userID int64) error {
+	return b.answerChatJoinRequest(ctx, "declineChatJoinRequest", chatID, userID)
+}
+
+// answerChatJoinRequest выполняет запрос одобрения или отклонения заявки.
+func (b *botClient) answerChatJoinRequest(ctx context.Context, method string, chatID ChatID, userID int64) error {
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"user_id": userID,
+	}
+	if err := b.call(ctx, method, payload, nil); err != nil {
+		return err
+	}
+	b.logger.Info("Chat join request answered", Field{"method", method}, Field{"chat_id", chatID}, Field{"user_id", userID})
+	return nil
+}
+
+// SendGame вызывает sendGame. Клавиатура, если задана, должна первой кнопкой содержать кнопку запуска игры.
+func (b *botClient) SendGame(ctx context.Context, chatID ChatID, gameShortName string, opts ...SendOption) (Message, error) {
+	payload := map[string]interface{}{
+		"chat_id":         chatID,
+		"game_short_name": gameShortName,
+	}
+	newSendOptions(opts).apply(payload)
+	var msg Message
+	if err := b.call(ctx, "sendGame", payload, &msg); err != nil {
+		return Message{}, err
+	}
+	b.logger.Info("Game sent successfully", Field{"chat_id", chatID}, Field{"game", gameShortName})
+	return msg, nil
+}
+
+// SetGameScore вызывает setGameScore для сообщения с игрой ref.
+func (b *botClient) SetGameScore(ctx context.Context, userID int64, score int, ref GameMessageRef, force bool) error {
+	payload := map[string]interface{}{
+		"user_id": userID,
+		"score":   score,
+	}
+	if force {
+		payload["force"] = true
+	}
+	ref.apply(payload)
+	// Для обычных сообщений Telegram возвращает Message, для inline – true; результат не нужен.
+	if err := b.call(ctx, "setGameScore", payload, nil); err != nil {
+		return err
+	}
+	b.logger.Info("Game score set", Field{"user_id", userID}, Field{"score", score})
+	return nil
+}
+
+// GetGameHighScores вызывает getGameHighScores для сообщения с игрой ref.
+func (b *botClient) GetGameHighScores(ctx context.Context, userID int64, ref GameMessageRef) ([]GameHighScore, error) {
+	payload := map[string]interface{}{
+		"user_id": userID,
+	}
+	ref.apply(payload)
+	var scores []GameHighScore
+	if err := b.call(ctx, "getGameHighScores", payload, &scores); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return scores, nil
+}
+
+const (
+	// humanizedDelayPerRune – имитируемое время набора одного символа.
+	humanizedDelayPerRune = 30 * time.Millisecond
+	// humanizedMinDelay и humanizedMaxDelay ограничивают паузу SendMessageHumanized.
+	// Статус typing в Telegram гаснет через 5 секунд, поэтому пауза не превышает этого времени.
+	humanizedMinDelay = 500 * time.Millisecond
+	humanizedMaxDelay = 5 * time.Second
+)
+
+// humanizedDelay вычисляет паузу перед отправкой текста.
+func humanizedDelay(text string) time.Duration {
+	delay := time.Duration(utf8.RuneCountInString(text)) * humanizedDelayPerRune
+	if delay < humanizedMinDelay {
+		return humanizedMinDelay
+	}
+	if delay > humanizedMaxDelay {
+		return humanizedMaxDelay
+	}
+	return delay
+}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+// SendMessageHumanized отправляет действие "typing", ждёт humanizedDelay(text) и отправляет сообщение.
+// Ошибка отправки действия только логируется. При отмене ctx во время паузы сообщение
+// не отправляется и возвращается ctx.Err().
+func (b *botClient) SendMessageHumanized(ctx context.Context, chatID ChatID, text string, opts ...SendOption) error {
+	options, err := b.sendOptions("sendMessage", nil, opts)
 	if err != nil {
-		b.logger.Error("Error reading getChatAdministrators response", Field{"error", err})
-		return nil, err
+		return err
 	}
-	var result struct {
-		OK     bool   `json:"ok"`
-		Result []Chat `json:"result"`
+	if err := b.SendChatAction(ctx, chatID, ChatActionTyping); err != nil {
+		b.logger.Warn("Failed to send typing action", Field{"chat_id", chatID}, Field{"error", err})
 	}
-	if err = json.Unmarshal(bodyBytes, &result); err != nil {
-		b.logger.Error("Error unmarshalling getChatAdministrators response", Field{"error", err})
-		return nil, err
+	timer := time.NewTimer(humanizedDelay(text))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
 	}
-	if !result.OK {
-		b.logger.Error("Telegram API returned not OK for getChatAdministrators", Field{"response", string(bodyBytes)})
-		return nil, fmt.Errorf("getChatAdministrators failed with response: %s", string(bodyBytes))
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
 	}
-	b.logger.Info("Chat administrators retrieved", Field{"chat_id", chatID}, Field{"count", len(result.Result)})
-	return result.Result, nil
+	options.apply(payload)
+	if _, err := b.sendMessage(ctx, payload); err != nil {
+		return err
+	}
+	b.logger.Info("Message sent successfully", Field{"chat_id", chatID})
+	return nil
 }
 
-func (b *botClient) GetMe(ctx context.Context) (User, error) {
-	endpoint := fmt.Sprintf("%s/getMe", b.apiURL)
+// Действия для SendChatAction.
+const (
+	ChatActionTyping          = "typing"
+	ChatActionUploadPhoto     = "upload_photo"
+	ChatActionRecordVideo     = "record_video"
+	ChatActionUploadVideo     = "upload_video"
+	ChatActionRecordVoice     = "record_voice"
+	ChatActionUploadVoice     = "upload_voice"
+	ChatActionUploadDocument  = "upload_document"
+	ChatActionChooseSticker   = "choose_sticker"
+	ChatActionFindLocation    = "find_location"
+	ChatActionRecordVideoNote = "record_video_note"
+	ChatActionUploadVideoNote = "upload_video_note"
+)
+
+// SendChatAction показывает в чате статус действия бота (например, ChatActionTyping).
+// Telegram сбрасывает статус через 5 секунд или при отправке ботом сообщения.
+func (b *botClient) SendChatAction(ctx context.Context, chatID ChatID, action string) error {
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"action":  action,
+	}
+	if err := b.call(ctx, "sendChatAction", payload, nil); err != nil {
+		return err
+	}
+	b.logger.Info("Chat action sent", Field{"chat_id", chatID}, Field{"action", action})
+	return nil
+}
+
+// sendMessage вызывает sendMessage с готовым payload и возвращает созданное сообщение.
+func (b *botClient) sendMessage(ctx context.Context, payload map[string]interface{}) (Message, error) {
+	b.applyDefaults(payload)
+	var msg Message
+	if err := b.call(ctx, "sendMessage", payload, &msg); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+// call выполняет POST-запрос к методу Telegram API с JSON-телом и разбирает поле result в out.
+// Если out равен nil, результат игнорируется.
+func (b *botClient) call(ctx context.Context, method string, payload interface{}, out interface{}) error {
+	endpoint := fmt.Sprintf("%s/%s", b.apiURL, method)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		b.logger.Error("Failed to marshal "+method+" payload", Field{"error", err})
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		b.logger.Error("Failed to create "+method+" request", Field{"error", err})
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return b.do(method, req, out)
+}
+
+// get выполняет GET-запрос к методу Telegram API с параметрами params в строке запроса
+// и разбирает поле result в out.
+func (b *botClient) get(ctx context.Context, method string, params url.Values, out interface{}) error {
+	endpoint := fmt.Sprintf("%s/%s", b.apiURL, method)
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
 	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
-		b.logger.Error("Failed to create getMe request", Field{"error", err})
-		return User{}, err
+		b.logger.Error("Failed to create "+method+" request", Field{"error", err})
+		return err
 	}
+	return b.do(method, req, out)
+}
+
+// do выполняет подготовленный запрос к методу Telegram API и разбирает поле result в out.
+func (b *botClient) do(method string, req *http.Request, out interface{}) error {
 	var resp *http.Response
+	var err error
 	WithRecovery(b.logger, func() {
 		resp, err = b.httpClient.Do(req)
 	})
 	if err != nil {
-		b.logger.Error("Error executing getMe request", Field{"error", err})
-		return User{}, err
+		b.logger.Error("Error executing "+method+" request", Field{"error", err})
+		return err
 	}
 	defer resp.Body.Close()
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	respBody, err := ReadLimited(resp.Body, b.maxResponseSize)
 	if err != nil {
-		b.logger.Error("Error reading getMe response", Field{"error", err})
-		return User{}, err
+		b.logger.Error("Error reading "+method+" response", Field{"error", err})
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		b.logger.Error("Non-OK response from "+method, Field{"status", resp.Status}, Field{"body", string(respBody)})
+		return newAPIError(method, resp.StatusCode, respBody)
 	}
 	var result struct {
-		OK     bool `json:"ok"`
-		Result User `json:"result"`
+		OK     bool            `json:"ok"`
+		Result json.RawMessage `json:"result"`
 	}
-	if err = json.Unmarshal(bodyBytes, &result); err != nil {
-		b.logger.Error("Error unmarshalling getMe response", Field{"error", err})
-		return User{}, err
+	if err = json.Unmarshal(respBody, &result); err != nil {
+		b.logger.Error("Error unmarshalling "+method+" response", Field{"error", err})
+		return err
 	}
 	if !result.OK {
-		b.logger.Error("Telegram API returned not OK for getMe", Field{"response", string(bodyBytes)})
-		return User{}, fmt.Errorf("getMe failed with response: %s", string(bodyBytes))
+		b.logger.Error("Telegram API returned not OK for "+method, Field{"response", string(respBody)})
+		return newAPIError(method, resp.StatusCode, respBody)
 	}
-	b.logger.Info("GetMe executed successfully")
-	return result.Result, nil
+	if out != nil {
+		if err = json.Unmarshal(result.Result, out); err != nil {
+			b.logger.Error("Error unmarshalling "+method+" result", Field{"error", err})
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,540 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient возвращает клиент, направляющий запросы на тестовый сервер, и записывающий их recorder.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*botClient, *CallRecorder) {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	recorder := NewCallRecorder()
+	api := NewBotClient("TEST_TOKEN", newTestLogger(), ts.Client(), WithCallRecorder(recorder)).(*botClient)
+	api.apiURL = ts.URL + "/botTEST_TOKEN"
+	return api, recorder
+}
+
+// okHandler отвечает успешным ответом Bot API с result = true.
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{"ok":true,"result":true}`))
+}
+
+func TestEditMessageReplyMarkupNilRemovesKeyboard(t *testing.T) {
+	type keyboard struct {
+		InlineKeyboard [][]string `json:"inline_keyboard"`
+	}
+	api, recorder := newTestClient(t, okHandler)
+
+	var typedNil *keyboard
+	for _, markup := range []interface{}{nil, typedNil} {
+		recorder.Reset()
+		if err := api.EditMessageReplyMarkup(context.Background(), ChatIDFromInt(1), 2, markup); err != nil {
+			t.Fatalf("EditMessageReplyMarkup: %v", err)
+		}
+		if _, ok := recorder.Calls()[0].Params["reply_markup"]; ok {
+			t.Errorf("markup %#v: reply_markup must be omitted", markup)
+		}
+	}
+
+	recorder.Reset()
+	if err := api.EditMessageReplyMarkup(context.Background(), ChatIDFromInt(1), 2, &keyboard{}); err != nil {
+		t.Fatalf("EditMessageReplyMarkup: %v", err)
+	}
+	if _, ok := recorder.Calls()[0].Params["reply_markup"]; !ok {
+		t.Error("reply_markup must be sent for non-nil markup")
+	}
+}
+
+func TestSetPassportDataErrors(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	elementErrors := []PassportElementError{
+		NewPassportDataFieldError("passport", "document_no", "hash1", "Invalid number"),
+		NewPassportFilesError("utility_bill", []string{"h1", "h2"}, "Scans are blurry"),
+	}
+	if err := api.SetPassportDataErrors(context.Background(), 7, elementErrors); err != nil {
+		t.Fatalf("SetPassportDataErrors: %v", err)
+	}
+	params := recorder.CallsTo("setPassportDataErrors")[0].Params
+	sent, ok := params["errors"].([]interface{})
+	if !ok || len(sent) != 2 {
+		t.Fatalf("unexpected errors param: %#v", params["errors"])
+	}
+	first := sent[0].(map[string]interface{})
+	if first["source"] != "data" || first["field_name"] != "document_no" || first["data_hash"] != "hash1" {
+		t.Errorf("unexpected first error: %v", first)
+	}
+	if _, ok := first["file_hash"]; ok {
+		t.Error("unused hash fields must be omitted")
+	}
+}
+
+func TestSendMessageHumanizedCancelled(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := api.SendMessageHumanized(ctx, ChatIDFromInt(1), "hello"); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if len(recorder.CallsTo("sendMessage")) != 0 {
+		t.Error("message must not be sent after cancellation")
+	}
+}
+
+func TestHumanizedDelayBounds(t *testing.T) {
+	if d := humanizedDelay("hi"); d != humanizedMinDelay {
+		t.Errorf("short text delay = %v", d)
+	}
+	if d := humanizedDelay(string(make([]rune, 1000))); d != humanizedMaxDelay {
+		t.Errorf("long text delay = %v", d)
+	}
+}
+
+func TestGetGameHighScores(t *testing.T) {
+	api, recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":[{"position":1,"user":{"id":5,"is_bot":false,"first_name":"A"},"score":42}]}`))
+	})
+	scores, err := api.GetGameHighScores(context.Background(), 5, GameMessageRef{InlineMessageID: "inline-1"})
+	if err != nil {
+		t.Fatalf("GetGameHighScores: %v", err)
+	}
+	if len(scores) != 1 || scores[0].Score != 42 || scores[0].User.ID != 5 {
+		t.Errorf("unexpected scores: %+v", scores)
+	}
+	params := recorder.Calls()[0].Params
+	if params["inline_message_id"] != "inline-1" {
+		t.Errorf("unexpected params: %v", params)
+	}
+	if _, ok := params["chat_id"]; ok {
+		t.Error("chat_id must be omitted for inline messages")
+	}
+}
+
+func TestSendMessageAppliesOptions(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	err := api.SendMessage(context.Background(), ChatIDFromInt(1), "hi",
+		WithParseMode("HTML"),
+		WithReplyTo(10),
+		WithMessageThreadID(3),
+		WithProtectContent(),
+		WithoutLinkPreview(),
+		WithBusinessConnection("bc-1"),
+		WithAllowPaidBroadcast(),
+	)
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	params := recorder.Calls()[0].Params
+	if params["parse_mode"] != "HTML" || params["message_thread_id"] != json.Number("3") || params["protect_content"] != true ||
+		params["business_connection_id"] != "bc-1" || params["allow_paid_broadcast"] != true {
+		t.Errorf("unexpected params: %v", params)
+	}
+	if preview, _ := params["link_preview_options"].(map[string]interface{}); preview["is_disabled"] != true {
+		t.Errorf("unexpected link_preview_options: %v", params["link_preview_options"])
+	}
+	if reply, _ := params["reply_parameters"].(map[string]interface{}); reply["message_id"] != json.Number("10") {
+		t.Errorf("unexpected reply_parameters: %v", params["reply_parameters"])
+	}
+}
+
+func TestParseModeOnTextAndCaption(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	ctx := context.Background()
+	calls := []func() error{
+		func() error {
+			return api.SendMessage(ctx, ChatIDFromInt(1), "*hi*", WithParseMode(ParseModeMarkdownV2))
+		},
+		func() error {
+			return api.SendMessageWithMarkup(ctx, ChatIDFromInt(1), "*hi*", nil, WithParseMode(ParseModeMarkdownV2))
+		},
+		func() error {
+			return api.SendPhoto(ctx, ChatIDFromInt(1), "photo-id", "*hi*", nil, WithParseMode(ParseModeMarkdownV2))
+		},
+		func() error {
+			return api.EditMessageText(ctx, ChatIDFromInt(1), 2, "*hi*", nil, WithParseMode(ParseModeMarkdownV2))
+		},
+		func() error { return api.SendMessage(ctx, ChatIDFromInt(1), "plain") },
+	}
+	for _, call := range calls {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorded := recorder.Calls()
+	for _, call := range recorded[:4] {
+		if call.Params["parse_mode"] != ParseModeMarkdownV2 {
+			t.Errorf("%s: parse_mode = %v", call.Method, call.Params["parse_mode"])
+		}
+	}
+	if _, ok := recorded[4].Params["parse_mode"]; ok {
+		t.Error("parse_mode must be omitted when not set")
+	}
+}
+
+func TestEntitiesOnTextAndCaption(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	ctx := context.Background()
+	entities := []MessageEntity{{Type: "bold", Offset: 0, Length: 2}}
+	if err := api.SendMessage(ctx, ChatIDFromInt(1), "hi", WithEntities(entities)); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.SendPhoto(ctx, ChatIDFromInt(1), "photo-id", "hi", nil, WithEntities(entities)); err != nil {
+		t.Fatal(err)
+	}
+	text, caption := recorder.Calls()[0].Params, recorder.Calls()[1].Params
+	if _, ok := text["entities"]; !ok {
+		t.Errorf("sendMessage: entities missing: %v", text)
+	}
+	if _, ok := caption["caption_entities"]; !ok {
+		t.Errorf("sendPhoto: caption_entities missing: %v", caption)
+	}
+	if _, ok := caption["entities"]; ok {
+		t.Errorf("sendPhoto must not send entities: %v", caption)
+	}
+}
+
+func TestSendMessageReturning(t *testing.T) {
+	api, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":77,"chat":{"id":1},"text":"hi"}}`))
+	})
+	msg, err := api.SendMessageReturning(context.Background(), ChatIDFromInt(1), "hi")
+	if err != nil {
+		t.Fatalf("SendMessageReturning: %v", err)
+	}
+	if msg.MessageID != 77 || msg.Chat.ID != 1 || msg.Text != "hi" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+}
+
+func TestSendMessageWithMarkupRejectsInvalidMarkup(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	for _, markup := range []interface{}{"not a keyboard", map[string]int{"rows": 1}, ForceReply{}} {
+		err := api.SendMessageWithMarkup(context.Background(), ChatIDFromInt(1), "hi", markup)
+		if !errors.Is(err, ErrInvalidReplyMarkup) {
+			t.Errorf("%#v: got %v, want ErrInvalidReplyMarkup", markup, err)
+		}
+	}
+	if len(recorder.Calls()) != 0 {
+		t.Errorf("invalid markup must not reach Telegram, got %d calls", len(recorder.Calls()))
+	}
+	if err := api.SendMessageWithMarkup(context.Background(), ChatIDFromInt(1), "hi", ReplyKeyboardRemove{RemoveKeyboard: true}); err != nil {
+		t.Errorf("ReplyKeyboardRemove rejected: %v", err)
+	}
+	serialized := `{"inline_keyboard":[[{"text":"OK","callback_data":"ok"}]]}`
+	for _, markup := range []interface{}{serialized, json.RawMessage(serialized)} {
+		recorder.Reset()
+		if err := api.SendMessageWithMarkup(context.Background(), ChatIDFromInt(1), "hi", markup); err != nil {
+			t.Errorf("%T markup rejected: %v", markup, err)
+			continue
+		}
+		if len(recorder.Calls()) != 1 || recorder.Calls()[0].Params["reply_markup"] == nil {
+			t.Errorf("%T markup was not forwarded: %+v", markup, recorder.Calls())
+		}
+	}
+	if err := api.SendMessageWithMarkup(context.Background(), ChatIDFromInt(1), "hi", `{"rows":1}`); !errors.Is(err, ErrInvalidReplyMarkup) {
+		t.Errorf("serialized markup without keyboard: got %v, want ErrInvalidReplyMarkup", err)
+	}
+}
+
+func TestReplyMarkupFromOptionsIsValidated(t *testing.T) {
+	api, recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":7}}`))
+	})
+	ctx := context.Background()
+	if err := api.SendPhoto(ctx, ChatIDFromInt(1), "photo-id", "", nil, WithReplyMarkup(ForceReply{})); !errors.Is(err, ErrInvalidReplyMarkup) {
+		t.Errorf("SendPhoto: got %v, want ErrInvalidReplyMarkup", err)
+	}
+	for _, result := range api.Broadcast(ctx, []ChatID{ChatIDFromInt(1), ChatIDFromInt(2)}, "hi", WithReplyMarkup("not a keyboard")) {
+		if !errors.Is(result.Err, ErrInvalidReplyMarkup) {
+			t.Errorf("Broadcast to %v: got %v, want ErrInvalidReplyMarkup", result.ChatID, result.Err)
+		}
+	}
+	if len(recorder.Calls()) != 0 {
+		t.Fatalf("invalid markup must not reach Telegram, got %d calls", len(recorder.Calls()))
+	}
+
+	err := api.SendDocument(ctx, ChatIDFromInt(1), "doc-id", "", ReplyKeyboardRemove{RemoveKeyboard: true}, WithReplyMarkup(ForceReply{ForceReply: true}))
+	if err != nil {
+		t.Fatalf("SendDocument: %v", err)
+	}
+	if markup, _ := recorder.Calls()[0].Params["reply_markup"].(map[string]interface{}); markup["remove_keyboard"] != true {
+		t.Errorf("replyMarkup parameter must take precedence, got %v", recorder.Calls()[0].Params["reply_markup"])
+	}
+
+	recorder.Reset()
+	results := api.Broadcast(ctx, []ChatID{ChatIDFromInt(1), ChatIDFromInt(2)}, "hi", WithDisableNotification())
+	for _, result := range results {
+		if result.Err != nil || result.MessageID != 7 {
+			t.Errorf("Broadcast to %v: %+v", result.ChatID, result)
+		}
+	}
+	for _, call := range recorder.Calls() {
+		if call.Params["disable_notification"] != true {
+			t.Errorf("Broadcast did not apply options: %v", call.Params)
+		}
+	}
+}
+
+func TestSendMediaGroupValidatesSize(t *testing.T) {
+	api, recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":[{"message_id":1},{"message_id":2}]}`))
+	})
+	photo := InputMedia{Type: "photo", Media: "file-id"}
+	for _, n := range []int{0, 1, 11} {
+		media := make([]InputMedia, n)
+		if _, err := api.SendMediaGroup(context.Background(), ChatIDFromInt(1), media); err == nil {
+			t.Errorf("%d items: expected error", n)
+		}
+	}
+	if len(recorder.Calls()) != 0 {
+		t.Fatalf("invalid albums must not be sent, got %d calls", len(recorder.Calls()))
+	}
+	messages, err := api.SendMediaGroup(context.Background(), ChatIDFromInt(1), []InputMedia{photo, photo})
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("SendMediaGroup: %v, %d messages", err, len(messages))
+	}
+	if media, _ := recorder.Calls()[0].Params["media"].([]interface{}); len(media) != 2 {
+		t.Errorf("unexpected media param: %v", recorder.Calls()[0].Params["media"])
+	}
+}
+
+func TestAPIErrorFromResponse(t *testing.T) {
+	api, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/botTEST_TOKEN/getChat" {
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`))
+	})
+	var apiErr *APIError
+	err := api.SendMessage(context.Background(), ChatIDFromInt(1), "hi")
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != 403 || apiErr.Method != "sendMessage" {
+		t.Fatalf("got %v, want 403 APIError", err)
+	}
+	if _, err := api.GetChat(context.Background(), ChatIDFromInt(1)); !errors.As(err, &apiErr) || apiErr.ErrorCode != 400 {
+		t.Fatalf("got %v, want 400 APIError", err)
+	}
+	if err := api.SetMyCommands(context.Background(), nil); !errors.As(err, &apiErr) || apiErr.Description != "Forbidden: bot was blocked by the user" {
+		t.Fatalf("got %v, want APIError from call", err)
+	}
+}
+
+func TestAPIErrorFromReadMethods(t *testing.T) {
+	api, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/botTEST_TOKEN/getUpdates" {
+			w.Write([]byte(`{"ok":false,"error_code":409,"description":"Conflict: terminated by other getUpdates request"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>502 Bad Gateway</html>"))
+	})
+	var apiErr *APIError
+	if _, err := api.GetUpdates(context.Background(), 0, 100, 0); !errors.As(err, &apiErr) || apiErr.ErrorCode != 409 || apiErr.Method != "getUpdates" {
+		t.Fatalf("got %v, want 409 APIError from getUpdates", err)
+	}
+	if _, err := api.GetMe(context.Background()); !errors.As(err, &apiErr) || apiErr.ErrorCode != 502 || apiErr.Method != "getMe" {
+		t.Fatalf("got %v, want 502 APIError from getMe", err)
+	}
+	if _, err := api.GetChatAdministrators(context.Background(), ChatIDFromInt(1)); !errors.As(err, &apiErr) || apiErr.ErrorCode != 502 {
+		t.Fatalf("got %v, want 502 APIError from getChatAdministrators", err)
+	}
+}
+
+func TestAPIErrorRetryAfter(t *testing.T) {
+	err := newAPIError("sendMessage", 429, []byte(`{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":7}}`))
+	if err.RetryAfter != 7 || err.Error() != "sendMessage failed with error 429: Too Many Requests (retry after 7s)" {
+		t.Errorf("unexpected error: %+v, %q", err, err.Error())
+	}
+	if plain := newAPIError("getMe", 502, nil); plain.ErrorCode != 502 || plain.Description != "Bad Gateway" {
+		t.Errorf("unexpected fallback error: %+v", plain)
+	}
+}
+
+func TestSendChatAction(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	if err := api.SendChatAction(context.Background(), ChatIDFromInt(5), ChatActionUploadDocument); err != nil {
+		t.Fatalf("SendChatAction: %v", err)
+	}
+	call := recorder.Calls()[0]
+	if call.Method != "sendChatAction" || call.Params["chat_id"] != json.Number("5") || call.Params["action"] != "upload_document" {
+		t.Errorf("unexpected call: %+v", call)
+	}
+}
+
+func TestGetMeParsesBotFlags(t *testing.T) {
+	api, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot","username":"test_bot",
+			"can_join_groups":true,"can_read_all_group_messages":false,"supports_inline_queries":true}}`))
+	})
+	me, err := api.GetMe(context.Background())
+	if err != nil {
+		t.Fatalf("GetMe: %v", err)
+	}
+	if !me.IsBot || !me.CanJoinGroups || me.CanReadAllGroupMessages || !me.SupportsInlineQueries {
+		t.Errorf("unexpected bot flags: %+v", me)
+	}
+}
+
+func TestGetChatParsesLinkedChat(t *testing.T) {
+	api, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"id":-1001,"type":"supergroup","title":"Comments",
+			"linked_chat_id":-1002,"slow_mode_delay":30}}`))
+	})
+	chat, err := api.GetChat(context.Background(), ChatIDFromInt(-1001))
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if chat.LinkedChatID != -1002 || chat.SlowModeDelay != 30 {
+		t.Errorf("unexpected chat: %+v", chat)
+	}
+}
+
+func TestAnswerAndEdit(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	cb := CallbackQuery{ID: "cb1", Message: &Message{MessageID: 7, Chat: Chat{ID: 42}}}
+	if err := api.AnswerAndEdit(context.Background(), cb, "done", nil); err != nil {
+		t.Fatalf("AnswerAndEdit: %v", err)
+	}
+	calls := recorder.Calls()
+	if len(calls) != 2 || calls[0].Method != "answerCallbackQuery" || calls[1].Method != "editMessageText" {
+		t.Fatalf("unexpected calls: %+v", calls)
+	}
+	edit := calls[1].Params
+	if edit["chat_id"] != json.Number("42") || edit["message_id"] != json.Number("7") || edit["text"] != "done" {
+		t.Errorf("unexpected edit params: %v", edit)
+	}
+
+	recorder.Reset()
+	if err := api.AnswerAndEdit(context.Background(), CallbackQuery{ID: "cb2"}, "done", nil); err != ErrNoCallbackMessage {
+		t.Errorf("got %v, want ErrNoCallbackMessage", err)
+	}
+}
+
+func TestGetUpdatesFitsTimeoutToDeadline(t *testing.T) {
+	api, recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := api.GetUpdates(ctx, 0, 100, 60); err != nil {
+		t.Fatalf("GetUpdates: %v", err)
+	}
+	if got := recorder.Calls()[0].Params["timeout"]; got != "8" {
+		t.Errorf("timeout = %v, want 8", got)
+	}
+
+	recorder.Reset()
+	if _, err := api.GetUpdates(context.Background(), 0, 100, 60); err != nil {
+		t.Fatalf("GetUpdates: %v", err)
+	}
+	if got := recorder.Calls()[0].Params["timeout"]; got != "60" {
+		t.Errorf("timeout without deadline = %v, want 60", got)
+	}
+}
+
+func TestRegisterWithTelegram(t *testing.T) {
+	api, recorder := newTestClient(t, okHandler)
+	router := NewRouter(newTestLogger())
+	router.HandleCommandWithDescription("/start", "Start", func(update Update) error { return nil })
+	if err := router.RegisterWithTelegram(context.Background(), api); err != nil {
+		t.Fatalf("RegisterWithTelegram: %v", err)
+	}
+	calls := recorder.CallsTo("setMyCommands")
+	if len(calls) != 1 {
+		t.Fatalf("got %d setMyCommands calls, want 1", len(calls))
+	}
+	commands, _ := calls[0].Params["commands"].([]interface{})
+	if len(commands) != 1 || commands[0].(map[string]interface{})["command"] != "start" {
+		t.Errorf("unexpected commands: %v", calls[0].Params["commands"])
+	}
+}
+
+func TestForwardAndCopyIntoForumTopic(t *testing.T) {
+	api, recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":99}}`))
+	})
+	if err := api.ForwardMessage(context.Background(), ChatIDFromInt(1), ChatIDFromInt(2), 3, WithMessageThreadID(7)); err != nil {
+		t.Fatalf("ForwardMessage: %v", err)
+	}
+	id, err := api.CopyMessage(context.Background(), ChatIDFromInt(1), ChatIDFromInt(2), 3, WithMessageThreadID(8))
+	if err != nil {
+		t.Fatalf("CopyMessage: %v", err)
+	}
+	if id != 99 {
+		t.Errorf("CopyMessage returned %d, want 99", id)
+	}
+	calls := recorder.Calls()
+	if calls[0].Params["message_thread_id"] != json.Number("7") || calls[1].Params["message_thread_id"] != json.Number("8") {
+		t.Errorf("unexpected params: %v, %v", calls[0].Params, calls[1].Params)
+	}
+}
+
+func TestChatIDAddressesChannelByName(t *testing.T) {
+	var photoChatID string
+	api, recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/sendPhoto") {
+			photoChatID = r.FormValue("chat_id")
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":3,"id":-1001,"chat":{"id":-1001}}}`))
+	})
+	ctx := context.Background()
+	channel := ChatIDFromUsername("mychannel")
+	if msg, err := api.SendMessageReturning(ctx, channel, "news"); err != nil || msg.MessageID != 3 {
+		t.Fatalf("SendMessageReturning: %+v, %v", msg, err)
+	}
+	if err := api.SendPhoto(ctx, channel, NewFileUpload("a.jpg", strings.NewReader("jpg")), "", nil); err != nil {
+		t.Fatalf("SendPhoto: %v", err)
+	}
+	if _, err := api.GetChat(ctx, ChatIDFromUsername("@mychannel")); err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	calls := recorder.CallsTo("sendMessage")
+	calls = append(calls, recorder.CallsTo("getChat")...)
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want 2", len(calls))
+	}
+	for _, call := range calls {
+		if call.Params["chat_id"] != "@mychannel" {
+			t.Errorf("%s: chat_id = %v, want @mychannel", call.Method, call.Params["chat_id"])
+		}
+	}
+	if photoChatID != "@mychannel" {
+		t.Errorf("sendPhoto form chat_id = %q, want @mychannel", photoChatID)
+	}
+	if err := api.SendMessage(ctx, ChatID{}, "news"); err == nil {
+		t.Error("empty chat id must be rejected")
+	}
+	if _, err := api.GetChat(ctx, ChatIDFromUsername("")); err == nil {
+		t.Error("empty username must be rejected")
+	}
+}
+
+func TestSendLongMessageRebasesEntities(t *testing.T) {
+	api, recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	})
+	text := strings.Repeat("a", MaxMessageLength) + "\ntail"
+	entities := []MessageEntity{{Type: "bold", Offset: MaxMessageLength + 1, Length: 4}}
+	if _, err := api.SendLongMessage(context.Background(), ChatIDFromInt(1), text, WithEntities(entities)); err != nil {
+		t.Fatalf("SendLongMessage: %v", err)
+	}
+	calls := recorder.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want 2", len(calls))
+	}
+	if _, ok := calls[0].Params["entities"]; ok {
+		t.Errorf("first part must not carry entities: %v", calls[0].Params["entities"])
+	}
+	got, _ := calls[1].Params["entities"].([]interface{})
+	if len(got) != 1 || got[0].(map[string]interface{})["offset"] != json.Number("0") {
+		t.Errorf("second part entities = %v, want offset 0", calls[1].Params["entities"])
+	}
+}
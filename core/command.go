@@ -0,0 +1,94 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// HelpText формирует текст справки из списка команд: по строке "/команда – описание" на команду.
+func HelpText(commands []BotCommand) string {
+	var b strings.Builder
+	for i, c := range commands {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("/" + c.Command)
+		if c.Description != "" {
+			b.WriteString(" – " + c.Description)
+		}
+	}
+	return b.String()
+}
+
+// HelpHandler возвращает обработчик, отвечающий справкой по командам router,
+// например для router.HandleCommandWithDescription("/help", "Список команд", HelpHandler(router, api)).
+// Справка строится при каждом вызове, поэтому всегда соответствует зарегистрированным командам.
+func HelpHandler(router Router, api BotAPI) HandlerFunc {
+	return func(update Update) error {
+		chat := update.EffectiveChat()
+		if chat == nil {
+			return nil
+		}
+		return api.SendMessage(update.Context(), ChatIDFromInt(chat.ID), HelpText(router.Commands()))
+	}
+}
+
+// ParseCommand разбирает текст сообщения с командой на саму команду и аргументы.
+// Аргументы разделяются любым количеством пробельных символов; подстроки в двойных
+// или одинарных кавычках считаются одним аргументом, а обратная косая черта
+// экранирует следующий символ. Например, `/add "multi word" 42` даёт
+// команду "/add" и аргументы ["multi word", "42"].
+//
+// Если текст не начинается с "/", возвращается пустая команда и nil.
+// Суффикс "@botname" у команды сохраняется.
+func ParseCommand(text string) (command string, args []string) {
+	text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	if !strings.HasPrefix(text, "/") {
+		return "", nil
+	}
+	tokens := splitArgs(text)
+	return tokens[0], tokens[1:]
+}
+
+// splitArgs разбивает строку на токены с учётом кавычек и экранирования.
+func splitArgs(s string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		quote   rune
+		escaped bool
+		inToken bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	cases := []struct {
+		text    string
+		command string
+		args    []string
+	}{
+		{"/start", "/start", []string{}},
+		{"/add   one\t two", "/add", []string{"one", "two"}},
+		{`/add "multi word" 42`, "/add", []string{"multi word", "42"}},
+		{`/say 'it''s' \"quoted\"`, "/say", []string{"its", `"quoted"`}},
+		{`/set key ""`, "/set", []string{"key", ""}},
+		{"/start@MyBot payload", "/start@MyBot", []string{"payload"}},
+		{"hello", "", nil},
+	}
+	for _, c := range cases {
+		command, args := ParseCommand(c.text)
+		if command != c.command || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("ParseCommand(%q) = %q, %q; want %q, %q", c.text, command, args, c.command, c.args)
+		}
+	}
+}
+
+func TestRouterMatchesCommandWithArguments(t *testing.T) {
+	router := NewRouter(newTestLogger())
+	called := false
+	router.HandleCommand("/add", func(update Update) error {
+		called = true
+		return nil
+	})
+	if err := router.Route(Update{Message: &Message{Text: `/add "multi word" 42`}}); err != nil {
+		t.Fatalf("Route returned error: %v", err)
+	}
+	if !called {
+		t.Fatal("command handler was not called for a command with arguments")
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewDedupLogger оборачивает base так, что одинаковые сообщения (уровень, текст и поля вызова)
+// в пределах окна window записываются один раз. По истечении окна подавленные повторы
+// сводятся в одну запись того же сообщения с полями "repeated" (число повторов) и "window".
+// Базовые поля WithFields (например, correlation ID) при сравнении не учитываются,
+// поэтому одна и та же ошибка из разных обновлений тоже схлопывается. Сообщения уровня
+// Fatal не подавляются. Сводка выводится при следующей записи через логгер после окна.
+func NewDedupLogger(base Logger, window time.Duration) Logger {
+	return &dedupLogger{
+		base: base,
+		state: &dedupState{
+			window:  window,
+			now:     time.Now,
+			entries: make(map[string]*dedupEntry),
+		},
+	}
+}
+
+// dedupLogger – Logger, подавляющий повторяющиеся сообщения.
+type dedupLogger struct {
+	base  Logger
+	state *dedupState
+}
+
+// dedupState – общее для логгеров из WithFields состояние подавления.
+type dedupState struct {
+	mu        sync.Mutex
+	window    time.Duration
+	now       func() time.Time
+	entries   map[string]*dedupEntry
+	lastSweep time.Time
+}
+
+// dedupEntry описывает сообщение, первое вхождение которого уже записано.
+type dedupEntry struct {
+	logger     Logger
+	level      LogLevel
+	msg        string
+	fields     []Field
+	since      time.Time
+	suppressed int
+}
+
+func (l *dedupLogger) Debug(msg string, fields ...Field) { l.log(DebugLevel, msg, fields) }
+func (l *dedupLogger) Info(msg string, fields ...Field)  { l.log(InfoLevel, msg, fields) }
+func (l *dedupLogger) Warn(msg string, fields ...Field)  { l.log(WarnLevel, msg, fields) }
+func (l *dedupLogger) Error(msg string, fields ...Field) { l.log(ErrorLevel, msg, fields) }
+func (l *dedupLogger) Fatal(msg string, fields ...Field) { l.base.Fatal(msg, fields...) }
+
+// WithFields возвращает логгер с дополнительными полями, разделяющий состояние с исходным.
+func (l *dedupLogger) WithFields(fields ...Field) Logger {
+	return &dedupLogger{base: l.base.WithFields(fields...), state: l.state}
+}
+
+// log записывает сообщение, если оно не повторяет недавнее, и выводит сводки по истёкшим окнам.
+func (l *dedupLogger) log(level LogLevel, msg string, fields []Field) {
+	summaries, allow := l.state.check(l.base, level, msg, fields)
+	for _, e := range summaries {
+		writeLevel(e.logger, e.level, e.msg, append(e.fields,
+			Field{"repeated", e.suppressed},
+			Field{"window", l.state.window.String()},
+		)...)
+	}
+	if allow {
+		writeLevel(l.base, level, msg, fields...)
+	}
+}
+
+// check учитывает сообщение и сообщает, нужно ли его записать. Также возвращает
+// истёкшие записи с подавленными повторами, по которым нужно вывести сводку.
+func (s *dedupState) check(logger Logger, level LogLevel, msg string, fields []Field) ([]*dedupEntry, bool) {
+	key := dedupKey(level, msg, fields)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+
+	var summaries []*dedupEntry
+	if now.Sub(s.lastSweep) >= s.window {
+		s.lastSweep = now
+		for k, e := range s.entries {
+			if now.Sub(e.since) >= s.window {
+				if e.suppressed > 0 {
+					summaries = append(summaries, e)
+				}
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	if e, exists := s.entries[key]; exists {
+		if now.Sub(e.since) < s.window {
+			e.suppressed++
+			return summaries, false
+		}
+		if e.suppressed > 0 {
+			summaries = append(summaries, e)
+		}
+	}
+	s.entries[key] = &dedupEntry{
+		logger: logger,
+		level:  level,
+		msg:    msg,
+		fields: append([]Field(nil), fields...),
+		since:  now,
+	}
+	return summaries, true
+}
+
+// dedupKey строит ключ сравнения сообщений.
+func dedupKey(level LogLevel, msg string, fields []Field) string {
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(0)
+	b.WriteString(msg)
+	for _, field := range fields {
+		fmt.Fprintf(&b, "\x00%s=%v", field.Key, field.Value)
+	}
+	return b.String()
+}
+
+// writeLevel вызывает метод logger, соответствующий level.
+func writeLevel(logger Logger, level LogLevel, msg string, fields ...Field) {
+	switch level {
+	case DebugLevel:
+		logger.Debug(msg, fields...)
+	case InfoLevel:
+		logger.Info(msg, fields...)
+	case WarnLevel:
+		logger.Warn(msg, fields...)
+	default:
+		logger.Error(msg, fields...)
+	}
+}
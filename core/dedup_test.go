@@ -0,0 +1,54 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// captureLogger запоминает записанные сообщения.
+type captureLogger struct {
+	entries *[]captured
+}
+
+type captured struct {
+	msg    string
+	fields []Field
+}
+
+func (c captureLogger) record(msg string, fields []Field) {
+	*c.entries = append(*c.entries, captured{msg, fields})
+}
+func (c captureLogger) Debug(msg string, fields ...Field) { c.record(msg, fields) }
+func (c captureLogger) Info(msg string, fields ...Field)  { c.record(msg, fields) }
+func (c captureLogger) Warn(msg string, fields ...Field)  { c.record(msg, fields) }
+func (c captureLogger) Error(msg string, fields ...Field) { c.record(msg, fields) }
+func (c captureLogger) Fatal(msg string, fields ...Field) { c.record(msg, fields) }
+func (c captureLogger) WithFields(fields ...Field) Logger { return c }
+
+func TestDedupLoggerCollapsesRepeats(t *testing.T) {
+	var entries []captured
+	now := time.Unix(1000, 0)
+	logger := NewDedupLogger(captureLogger{&entries}, time.Minute)
+	logger.(*dedupLogger).state.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		logger.WithFields(Field{"correlation_id", i}).Error("getUpdates failed", Field{"error", "timeout"})
+	}
+	logger.Error("getUpdates failed", Field{"error", "connection refused"})
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries within the window, want 2: %+v", len(entries), entries)
+	}
+
+	now = now.Add(time.Minute)
+	logger.Info("recovered")
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries after the window, want 4: %+v", len(entries), entries)
+	}
+	summary := entries[2]
+	if summary.msg != "getUpdates failed" || len(summary.fields) != 3 || summary.fields[1] != (Field{"repeated", 99}) {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if entries[3].msg != "recovered" {
+		t.Errorf("unexpected last entry: %+v", entries[3])
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+	"sync"
+)
+
+// Dispatcher передаёт каждое обновление всем зарегистрированным обработчикам.
+// В отличие от middleware обработчики не образуют цепочку: ошибка или паника
+// одного из них не мешает вызову остальных.
+//
+// Метод Dispatch совместим с HandlerFunc, поэтому диспетчер можно использовать
+// везде, где ожидается обработчик, а роутер добавить в него как router.Route.
+type Dispatcher struct {
+	mu         sync.RWMutex
+	handlers   []HandlerFunc
+	concurrent bool
+	logger     Logger
+}
+
+// DispatcherOption задаёт необязательные параметры Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithConcurrentDispatch включает параллельный вызов обработчиков.
+// Dispatch в этом случае дожидается завершения всех обработчиков.
+func WithConcurrentDispatch() DispatcherOption {
+	return func(d *Dispatcher) {
+		d.concurrent = true
+	}
+}
+
+// NewDispatcher создаёт пустой диспетчер с использованием переданного логгера.
+func NewDispatcher(logger Logger, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{logger: logger}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Add регистрирует обработчики, которые будут получать каждое обновление.
+func (d *Dispatcher) Add(handlers ...HandlerFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers = append(d.handlers, handlers...)
+	d.logger.Debug("Registered dispatcher handlers", Field{"count", len(handlers)})
+}
+
+// Dispatch вызывает все обработчики для обновления и возвращает объединённые
+// через errors.Join ошибки (nil, если все обработчики завершились успешно).
+func (d *Dispatcher) Dispatch(update Update) error {
+	d.mu.RLock()
+	handlers := make([]HandlerFunc, len(d.handlers))
+	copy(handlers, d.handlers)
+	d.mu.RUnlock()
+
+	errs := make([]error, len(handlers))
+	if d.concurrent {
+		var wg sync.WaitGroup
+		for i, handler := range handlers {
+			wg.Add(1)
+			go func(i int, handler HandlerFunc) {
+				defer wg.Done()
+				errs[i] = d.call(handler, update)
+			}(i, handler)
+		}
+		wg.Wait()
+	} else {
+		for i, handler := range handlers {
+			errs[i] = d.call(handler, update)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// call вызывает обработчик с перехватом паники.
+func (d *Dispatcher) call(handler HandlerFunc, update Update) error {
+	var err error
+	WithRecovery(d.logger, func() {
+		err = handler(update)
+	})
+	if err != nil {
+		d.logger.Error("Dispatcher handler returned error", Field{"update_id", update.UpdateID}, Field{"error", err})
+	}
+	return err
+}
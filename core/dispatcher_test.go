@@ -0,0 +1,76 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDispatcherFansOutAndJoinsErrors(t *testing.T) {
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	for _, opts := range [][]DispatcherOption{nil, {WithConcurrentDispatch()}} {
+		d := NewDispatcher(newTestLogger(), opts...)
+		var mu sync.Mutex
+		var seen []int
+		record := func(id int, err error) HandlerFunc {
+			return func(update Update) error {
+				mu.Lock()
+				seen = append(seen, update.UpdateID*10+id)
+				mu.Unlock()
+				return err
+			}
+		}
+		d.Add(record(1, errA), record(2, nil))
+		d.Add(record(3, errB))
+
+		err := d.Dispatch(Update{UpdateID: 7})
+		if len(seen) != 3 {
+			t.Errorf("concurrent=%v: %d handlers called, want 3", len(opts) > 0, len(seen))
+		}
+		if !errors.Is(err, errA) || !errors.Is(err, errB) {
+			t.Errorf("concurrent=%v: got %v, want both handler errors", len(opts) > 0, err)
+		}
+		if err := NewDispatcher(newTestLogger(), opts...).Dispatch(Update{}); err != nil {
+			t.Errorf("empty dispatcher returned %v", err)
+		}
+	}
+}
+
+func TestDispatcherPanicDoesNotBlockOthers(t *testing.T) {
+	for _, opts := range [][]DispatcherOption{nil, {WithConcurrentDispatch()}} {
+		d := NewDispatcher(newTestLogger(), opts...)
+		var calls int32
+		d.Add(
+			func(update Update) error { panic("boom") },
+			func(update Update) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			},
+		)
+		if err := d.Dispatch(Update{UpdateID: 1}); err != nil {
+			t.Errorf("concurrent=%v: got %v, want nil", len(opts) > 0, err)
+		}
+		if calls != 1 {
+			t.Errorf("concurrent=%v: handler after the panicking one called %d times", len(opts) > 0, calls)
+		}
+	}
+}
+
+func TestDispatcherConcurrentRunsHandlersInParallel(t *testing.T) {
+	d := NewDispatcher(newTestLogger(), WithConcurrentDispatch())
+	const n = 4
+	var started sync.WaitGroup
+	started.Add(n)
+	for i := 0; i < n; i++ {
+		d.Add(func(update Update) error {
+			// Каждый обработчик ждёт остальных: последовательный вызов здесь завис бы.
+			started.Done()
+			started.Wait()
+			return nil
+		})
+	}
+	if err := d.Dispatch(Update{UpdateID: 1}); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+}
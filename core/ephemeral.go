@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// selfDestructTimeout ограничивает запрос deleteMessage, выполняемый по таймеру.
+const selfDestructTimeout = 10 * time.Second
+
+// SelfDestructFlusher досрочно удаляет сообщения, отправленные SendSelfDestructing.
+// Его реализует клиент, созданный NewBotClient; интерфейс отделён от BotAPI, чтобы
+// другие реализации BotAPI не были обязаны его поддерживать.
+type SelfDestructFlusher interface {
+	// FlushSelfDestructing сразу удаляет все сообщения, ожидающие удаления по ttl.
+	FlushSelfDestructing(ctx context.Context) error
+}
+
+// pendingDelete – сообщение, удаление которого запланировано SendSelfDestructing.
+type pendingDelete struct {
+	chatID    ChatID
+	messageID int
+	timer     *time.Timer
+}
+
+// DeleteMessage удаляет сообщение messageID в чате chatID (deleteMessage).
+func (b *botClient) DeleteMessage(ctx context.Context, chatID ChatID, messageID int) error {
+	payload := map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+	}
+	if err := b.call(ctx, "deleteMessage", payload, nil); err != nil {
+		return err
+	}
+	b.logger.Info("Message deleted", Field{"chat_id", chatID}, Field{"message_id", messageID})
+	return nil
+}
+
+// SendSelfDestructing отправляет сообщение и планирует его удаление через ttl – например,
+// для одноразовых кодов и подсказок. Удаление выполняется в отдельной горутине по таймеру,
+// его ошибка только логируется. Чтобы при остановке бота не оставить сообщения в чатах,
+// зарегистрируйте сброс удалений (SelfDestructFlusher) в Lifecycle:
+//
+//	if flusher, ok := api.(core.SelfDestructFlusher); ok {
+//		lifecycle.Register("self-destructing messages", 10*time.Second, flusher.FlushSelfDestructing)
+//	}
+func (b *botClient) SendSelfDestructing(ctx context.Context, chatID ChatID, text string, ttl time.Duration, opts ...SendOption) error {
+	msg, err := b.SendMessageReturning(ctx, chatID, text, opts...)
+	if err != nil {
+		return err
+	}
+	p := &pendingDelete{chatID: chatID, messageID: msg.MessageID}
+	b.deletesMu.Lock()
+	defer b.deletesMu.Unlock()
+	if b.deletes == nil {
+		b.deletes = make(map[*pendingDelete]struct{})
+	}
+	b.deletes[p] = struct{}{}
+	p.timer = time.AfterFunc(ttl, func() {
+		if !b.takeDelete(p) {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), selfDestructTimeout)
+		defer cancel()
+		if err := b.DeleteMessage(ctx, p.chatID, p.messageID); err != nil {
+			b.logger.Error("Failed to delete self-destructing message", Field{"chat_id", p.chatID}, Field{"message_id", p.messageID}, Field{"error", err})
+		}
+	})
+	b.logger.Debug("Scheduled message deletion", Field{"chat_id", chatID}, Field{"message_id", msg.MessageID}, Field{"ttl", ttl.String()})
+	return nil
+}
+
+// FlushSelfDestructing отменяет таймеры SendSelfDestructing и сразу удаляет ожидающие
+// сообщения. Возвращает объединённые ошибки удаления.
+func (b *botClient) FlushSelfDestructing(ctx context.Context) error {
+	b.deletesMu.Lock()
+	pending := b.deletes
+	b.deletes = nil
+	b.deletesMu.Unlock()
+
+	var errs []error
+	for p := range pending {
+		p.timer.Stop()
+		if err := b.DeleteMessage(ctx, p.chatID, p.messageID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// takeDelete снимает p с учёта и сообщает, было ли удаление ещё запланировано.
+func (b *botClient) takeDelete(p *pendingDelete) bool {
+	b.deletesMu.Lock()
+	defer b.deletesMu.Unlock()
+	if _, ok := b.deletes[p]; !ok {
+		return false
+	}
+	delete(b.deletes, p)
+	return true
+}
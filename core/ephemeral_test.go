@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func sentMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/botTEST_TOKEN/sendMessage" {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":9,"chat":{"id":1}}}`))
+		return
+	}
+	okHandler(w, r)
+}
+
+func TestSendSelfDestructingDeletesAfterTTL(t *testing.T) {
+	api, recorder := newTestClient(t, sentMessageHandler)
+	if err := api.SendSelfDestructing(context.Background(), ChatIDFromInt(1), "code: 1234", 10*time.Millisecond); err != nil {
+		t.Fatalf("SendSelfDestructing: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.CallsTo("deleteMessage")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	deletes := recorder.CallsTo("deleteMessage")
+	if len(deletes) != 1 || deletes[0].Params["message_id"] != json.Number("9") {
+		t.Fatalf("unexpected deleteMessage calls: %+v", deletes)
+	}
+}
+
+func TestFlushSelfDestructing(t *testing.T) {
+	api, recorder := newTestClient(t, sentMessageHandler)
+	if err := api.SendSelfDestructing(context.Background(), ChatIDFromInt(1), "prompt", time.Hour); err != nil {
+		t.Fatalf("SendSelfDestructing: %v", err)
+	}
+	var client BotAPI = api
+	flusher, ok := client.(SelfDestructFlusher)
+	if !ok {
+		t.Fatal("bot client does not implement SelfDestructFlusher")
+	}
+	if err := flusher.FlushSelfDestructing(context.Background()); err != nil {
+		t.Fatalf("FlushSelfDestructing: %v", err)
+	}
+	if err := flusher.FlushSelfDestructing(context.Background()); err != nil {
+		t.Fatalf("second FlushSelfDestructing: %v", err)
+	}
+	if n := len(recorder.CallsTo("deleteMessage")); n != 1 {
+		t.Errorf("got %d deleteMessage calls, want 1", n)
+	}
+}
@@ -0,0 +1,106 @@
+package core
+
+import "context"
+
+// Цвета значков тем форума, допустимые в createForumTopic.
+const (
+	ForumTopicColorBlue   = 0x6FB9F0
+	ForumTopicColorYellow = 0xFFD67E
+	ForumTopicColorViolet = 0xCB86DB
+	ForumTopicColorGreen  = 0x8EEE98
+	ForumTopicColorRose   = 0xFF93B2
+	ForumTopicColorRed    = 0xFB6F5F
+)
+
+// ForumTopic represents a topic of a forum supergroup.
+type ForumTopic struct {
+	MessageThreadID   int    `json:"message_thread_id"`
+	Name              string `json:"name"`
+	IconColor         int    `json:"icon_color"`
+	IconCustomEmojiID string `json:"icon_custom_emoji_id,omitempty"`
+}
+
+// ForumTopicOption задаёт необязательные параметры создания и редактирования темы.
+type ForumTopicOption func(payload map[string]interface{})
+
+// WithTopicIconColor задаёт цвет значка новой темы (одна из констант ForumTopicColor*).
+// Цвет можно задать только при создании темы.
+func WithTopicIconColor(color int) ForumTopicOption {
+	return func(payload map[string]interface{}) {
+		payload["icon_color"] = color
+	}
+}
+
+// WithTopicIconCustomEmoji задаёт кастомный эмодзи значка темы.
+// При редактировании пустая строка убирает значок.
+func WithTopicIconCustomEmoji(customEmojiID string) ForumTopicOption {
+	return func(payload map[string]interface{}) {
+		payload["icon_custom_emoji_id"] = customEmojiID
+	}
+}
+
+// CreateForumTopic создаёт тему в форуме chatID (createForumTopic). Бот должен быть
+// администратором с правом can_manage_topics.
+func (b *botClient) CreateForumTopic(ctx context.Context, chatID ChatID, name string, opts ...ForumTopicOption) (ForumTopic, error) {
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"name":    name,
+	}
+	for _, opt := range opts {
+		opt(payload)
+	}
+	var topic ForumTopic
+	if err := b.call(ctx, "createForumTopic", payload, &topic); err != nil {
+		return ForumTopic{}, err
+	}
+	b.logger.Info("Forum topic created", Field{"chat_id", chatID}, Field{"message_thread_id", topic.MessageThreadID})
+	return topic, nil
+}
+
+// EditForumTopic меняет название темы (пустое name оставляет прежнее) и, через
+// WithTopicIconCustomEmoji, её значок (editForumTopic).
+func (b *botClient) EditForumTopic(ctx context.Context, chatID ChatID, messageThreadID int, name string, opts ...ForumTopicOption) error {
+	payload := map[string]interface{}{
+		"chat_id":           chatID,
+		"message_thread_id": messageThreadID,
+	}
+	if name != "" {
+		payload["name"] = name
+	}
+	for _, opt := range opts {
+		opt(payload)
+	}
+	if err := b.call(ctx, "editForumTopic", payload, nil); err != nil {
+		return err
+	}
+	b.logger.Info("Forum topic edited", Field{"chat_id", chatID}, Field{"message_thread_id", messageThreadID})
+	return nil
+}
+
+// CloseForumTopic закрывает тему (closeForumTopic).
+func (b *botClient) CloseForumTopic(ctx context.Context, chatID ChatID, messageThreadID int) error {
+	return b.forumTopicAction(ctx, "closeForumTopic", chatID, messageThreadID)
+}
+
+// ReopenForumTopic снова открывает закрытую тему (reopenForumTopic).
+func (b *botClient) ReopenForumTopic(ctx context.Context, chatID ChatID, messageThreadID int) error {
+	return b.forumTopicAction(ctx, "reopenForumTopic", chatID, messageThreadID)
+}
+
+// DeleteForumTopic удаляет тему вместе со всеми её сообщениями (deleteForumTopic).
+func (b *botClient) DeleteForumTopic(ctx context.Context, chatID ChatID, messageThreadID int) error {
+	return b.forumTopicAction(ctx, "deleteForumTopic", chatID, messageThreadID)
+}
+
+// forumTopicAction вызывает метод method для темы messageThreadID.
+func (b *botClient) forumTopicAction(ctx context.Context, method string, chatID ChatID, messageThreadID int) error {
+	payload := map[string]interface{}{
+		"chat_id":           chatID,
+		"message_thread_id": messageThreadID,
+	}
+	if err := b.call(ctx, method, payload, nil); err != nil {
+		return err
+	}
+	b.logger.Info("Forum topic updated", Field{"method", method}, Field{"chat_id", chatID}, Field{"message_thread_id", messageThreadID})
+	return nil
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestForumTopicLifecycle(t *testing.T) {
+	api, recorder := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/botTEST_TOKEN/createForumTopic" {
+			w.Write([]byte(`{"ok":true,"result":{"message_thread_id":42,"name":"Event","icon_color":7322096}}`))
+			return
+		}
+		okHandler(w, r)
+	})
+	ctx := context.Background()
+	topic, err := api.CreateForumTopic(ctx, ChatIDFromInt(-100), "Event", WithTopicIconColor(ForumTopicColorBlue))
+	if err != nil {
+		t.Fatalf("CreateForumTopic: %v", err)
+	}
+	if topic.MessageThreadID != 42 || topic.IconColor != ForumTopicColorBlue {
+		t.Errorf("unexpected topic: %+v", topic)
+	}
+	if err := api.EditForumTopic(ctx, ChatIDFromInt(-100), 42, "Renamed"); err != nil {
+		t.Fatalf("EditForumTopic: %v", err)
+	}
+	for _, fn := range []func(context.Context, ChatID, int) error{api.CloseForumTopic, api.ReopenForumTopic, api.DeleteForumTopic} {
+		if err := fn(ctx, ChatIDFromInt(-100), 42); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var methods []string
+	for _, call := range recorder.Calls() {
+		methods = append(methods, call.Method)
+		if call.Params["message_thread_id"] != nil && call.Params["message_thread_id"] != json.Number("42") {
+			t.Errorf("%s: unexpected message_thread_id %v", call.Method, call.Params["message_thread_id"])
+		}
+	}
+	want := []string{"createForumTopic", "editForumTopic", "closeForumTopic", "reopenForumTopic", "deleteForumTopic"}
+	if !reflect.DeepEqual(methods, want) {
+		t.Errorf("methods = %v, want %v", methods, want)
+	}
+}
@@ -1,10 +1,16 @@
 package core
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -57,18 +63,130 @@ type Logger interface {
 
 // defaultLogger – реализация Logger, которая выводит логи в JSON-формате
 type defaultLogger struct {
-	mu         sync.Mutex
+	// mu сериализует запись в out; общий для логгеров из WithFields, пишущих в тот же поток.
+	mu         *sync.Mutex
 	level      LogLevel
 	baseFields []Field
-	out        *os.File
+	out        io.Writer
+	// noFatalExit отключает завершение процесса в Fatal.
+	noFatalExit bool
+	// fatalPanic заменяет завершение процесса в Fatal паникой ErrFatalLogged.
+	fatalPanic bool
+	// sampler ограничивает число записей в единицу времени; общий для логгеров из WithFields.
+	sampler *logSampler
+	// format – формат записей: JSONFormat (по умолчанию) или TextFormat.
+	format LogFormat
 }
 
-// NewLogger создаёт новый логгер с заданным уровнем логирования (например, DebugLevel или InfoLevel)
-func NewLogger(level LogLevel) Logger {
-	return &defaultLogger{
+// LogFormat определяет формат записей логгера.
+type LogFormat int
+
+const (
+	// JSONFormat – запись в виде JSON-объекта в одну строку; формат по умолчанию.
+	JSONFormat LogFormat = iota
+	// TextFormat – удобочитаемая строка "2006-01-02T15:04:05 INFO message key=value"
+	// для локальной разработки. Поля сортируются по ключу.
+	TextFormat
+)
+
+// textTimeLayout – формат времени в записях TextFormat.
+const textTimeLayout = "2006-01-02T15:04:05"
+
+// LoggerOption задаёт необязательные параметры логгера.
+type LoggerOption func(*defaultLogger)
+
+// WithoutFatalExit отключает вызов os.Exit(1) в Fatal: сообщение записывается с уровнем FATAL,
+// после чего управление возвращается вызывающему коду, который сам решает, как завершаться.
+func WithoutFatalExit() LoggerOption {
+	return func(l *defaultLogger) {
+		l.noFatalExit = true
+	}
+}
+
+// ErrFatalLogged – значение паники, которой Fatal завершает текущую горутину
+// у логгера с опцией WithFatalPanic.
+var ErrFatalLogged = errors.New("fatal error logged")
+
+// WithFatalPanic заменяет os.Exit(1) в Fatal паникой со значением, оборачивающим ErrFatalLogged.
+// Внутри WithRecovery (а значит, и в обработчиках роутера) такая паника перехватывается:
+// обработчик прерывается, но бот продолжает работу. Вне WithRecovery паника, как и прежде,
+// завершает процесс. Опция WithoutFatalExit имеет приоритет: Fatal только записывает сообщение.
+func WithFatalPanic() LoggerOption {
+	return func(l *defaultLogger) {
+		l.fatalPanic = true
+	}
+}
+
+// WithFormat задаёт формат записей логгера (JSONFormat или TextFormat).
+func WithFormat(format LogFormat) LoggerOption {
+	return func(l *defaultLogger) {
+		l.format = format
+	}
+}
+
+// WithSampling включает сэмплирование: на каждом уровне за период period записываются только
+// первые first сообщений, остальные отбрасываются. Сообщения уровня Fatal не сэмплируются.
+// Логгеры, полученные через WithFields, используют общий счётчик с исходным.
+func WithSampling(first int, period time.Duration) LoggerOption {
+	return func(l *defaultLogger) {
+		if first <= 0 || period <= 0 {
+			return
+		}
+		l.sampler = &logSampler{first: first, period: period, now: time.Now}
+	}
+}
+
+// logSampler считает сообщения каждого уровня в пределах текущего окна.
+type logSampler struct {
+	mu          sync.Mutex
+	first       int
+	period      time.Duration
+	now         func() time.Time
+	windowStart [FatalLevel]time.Time
+	counts      [FatalLevel]int
+}
+
+// allow сообщает, нужно ли записать очередное сообщение уровня level.
+func (s *logSampler) allow(level LogLevel) bool {
+	if level < DebugLevel || level >= FatalLevel {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.windowStart[level]) >= s.period {
+		s.windowStart[level] = now
+		s.counts[level] = 0
+	}
+	s.counts[level]++
+	return s.counts[level] <= s.first
+}
+
+// NewLogger создаёт новый логгер с заданным уровнем логирования (например, DebugLevel или InfoLevel).
+// Записи выводятся в os.Stdout. Дополнительные параметры передаются через opts.
+func NewLogger(level LogLevel, opts ...LoggerOption) Logger {
+	return NewLoggerWithWriter(level, os.Stdout, opts...)
+}
+
+// NewLoggerWithFormat создаёт логгер, как NewLogger, с указанным форматом записей.
+// Например, NewLoggerWithFormat(DebugLevel, TextFormat) удобен при локальной разработке.
+func NewLoggerWithFormat(level LogLevel, format LogFormat, opts ...LoggerOption) Logger {
+	return NewLogger(level, append([]LoggerOption{WithFormat(format)}, opts...)...)
+}
+
+// NewLoggerWithWriter создаёт логгер, как NewLogger, но выводит записи в w: файл, буфер в тестах
+// и т.п. Логгеры, полученные через WithFields, пишут в тот же w. Запись в w сериализуется,
+// поэтому w не обязан быть потокобезопасным.
+func NewLoggerWithWriter(level LogLevel, w io.Writer, opts ...LoggerOption) Logger {
+	l := &defaultLogger{
+		mu:    &sync.Mutex{},
 		level: level,
-		out:   os.Stdout,
+		out:   w,
+	}
+	for _, opt := range opts {
+		opt(l)
 	}
+	return l
 }
 
 // WithFields возвращает новый логгер с добавлением указанных полей к базовым
@@ -77,9 +195,14 @@ func (l *defaultLogger) WithFields(fields ...Field) Logger {
 	copy(newBaseFields, l.baseFields)
 	newBaseFields = append(newBaseFields, fields...)
 	return &defaultLogger{
-		level:      l.level,
-		baseFields: newBaseFields,
-		out:        l.out,
+		mu:          l.mu,
+		level:       l.level,
+		baseFields:  newBaseFields,
+		out:         l.out,
+		noFatalExit: l.noFatalExit,
+		fatalPanic:  l.fatalPanic,
+		sampler:     l.sampler,
+		format:      l.format,
 	}
 }
 
@@ -89,34 +212,112 @@ func (l *defaultLogger) logf(level LogLevel, msg string, fields ...Field) {
 	if level < l.level {
 		return
 	}
-	entry := make(map[string]interface{})
-	entry["time"] = time.Now().Format(time.RFC3339)
-	entry["level"] = level.String()
-	entry["message"] = msg
-
-	// Объединяем базовые поля и поля, переданные в вызове
-	for _, field := range l.baseFields {
-		entry[field.Key] = field.Value
+	if l.sampler != nil && !l.sampler.allow(level) {
+		return
 	}
-	for _, field := range fields {
-		entry[field.Key] = field.Value
+	now := time.Now()
+	entry := []Field{
+		{"time", now.Format(time.RFC3339)},
+		{"level", level.String()},
+		{"message", msg},
 	}
+	// Объединяем базовые поля и поля, переданные в вызове, сохраняя порядок.
+	// Повторяющийся ключ остаётся на первой позиции и получает последнее значение.
+	entry = mergeFields(entry, l.baseFields)
+	entry = mergeFields(entry, fields)
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	// Преобразуем запись в JSON и выводим в заданный поток
-	b, err := json.Marshal(entry)
-	if err != nil {
-		fmt.Fprintf(l.out, "Error marshaling log entry: %v\n", err)
+	if l.format == TextFormat {
+		fmt.Fprintln(l.out, formatText(now, entry))
 	} else {
-		fmt.Fprintln(l.out, string(b))
+		// Преобразуем запись в JSON и выводим в заданный поток
+		b, err := marshalFields(entry)
+		if err != nil {
+			fmt.Fprintf(l.out, "Error marshaling log entry: %v\n", err)
+		} else {
+			fmt.Fprintln(l.out, string(b))
+		}
 	}
-	// Если уровень Fatal, завершаем выполнение программы
-	if level == FatalLevel {
+	// Если уровень Fatal, завершаем выполнение программы (если это не отключено опцией)
+	if level == FatalLevel && !l.noFatalExit {
+		if l.fatalPanic {
+			panic(fmt.Errorf("%w: %s", ErrFatalLogged, msg))
+		}
 		os.Exit(1)
 	}
 }
 
+// mergeFields добавляет fields к entry; значение существующего ключа заменяется на месте.
+func mergeFields(entry, fields []Field) []Field {
+	for _, field := range fields {
+		replaced := false
+		for i := range entry {
+			if entry[i].Key == field.Key {
+				entry[i].Value = field.Value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			entry = append(entry, field)
+		}
+	}
+	return entry
+}
+
+// marshalFields кодирует поля в JSON-объект, сохраняя их порядок.
+func marshalFields(fields []Field) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// formatText форматирует запись для TextFormat: время, уровень и сообщение (первые три поля entry),
+// затем остальные поля в виде key=value, отсортированные по ключу. Значения с пробелами,
+// кавычками или знаком "=" заключаются в кавычки.
+func formatText(now time.Time, entry []Field) string {
+	var buf strings.Builder
+	buf.WriteString(now.Format(textTimeLayout))
+	buf.WriteByte(' ')
+	fmt.Fprint(&buf, entry[1].Value)
+	buf.WriteByte(' ')
+	fmt.Fprint(&buf, entry[2].Value)
+
+	fields := append([]Field(nil), entry[3:]...)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+	for _, field := range fields {
+		value := fmt.Sprint(field.Value)
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(field.Key)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+	}
+	return buf.String()
+}
+
 func (l *defaultLogger) Debug(msg string, fields ...Field) {
 	l.logf(DebugLevel, msg, fields...)
 }
@@ -139,9 +340,16 @@ func (l *defaultLogger) Fatal(msg string, fields ...Field) {
 
 // WithRecovery выполняет переданную функцию fn и перехватывает панику, если она возникнет,
 // записывая подробное сообщение с информацией о панике и стеком вызовов.
+// Паника от Fatal логгера с WithFatalPanic тоже перехватывается, поэтому Fatal в обработчике
+// не останавливает бота. Логгер без WithFatalPanic или WithoutFatalExit вызывает os.Exit(1),
+// который перехватить нельзя: в обработчиках используйте Error или такой логгер.
 func WithRecovery(logger Logger, fn func()) {
 	defer func() {
 		if r := recover(); r != nil {
+			if err, ok := r.(error); ok && errors.Is(err, ErrFatalLogged) {
+				logger.Error("Fatal log recovered, process keeps running", Field{"error", err})
+				return
+			}
 			stack := string(debug.Stack())
 			logger.Error("Panic recovered",
 				Field{"panic", r},
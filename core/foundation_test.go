@@ -0,0 +1,141 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFatalWithoutExit(t *testing.T) {
+	out, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	logger := NewLogger(InfoLevel, WithoutFatalExit()).(*defaultLogger)
+	logger.out = out
+	logger.WithFields(Field{"component", "test"}).Fatal("dependency failed")
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"level":"FATAL"`) || !strings.Contains(string(data), `"component":"test"`) {
+		t.Errorf("unexpected log output: %s", data)
+	}
+}
+
+func TestFatalPanicIsRecovered(t *testing.T) {
+	out, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	logger := NewLogger(InfoLevel, WithFatalPanic()).(*defaultLogger)
+	logger.out = out
+	reached := false
+	WithRecovery(logger, func() {
+		logger.WithFields(Field{"component", "handler"}).Fatal("handler failed")
+		reached = true
+	})
+	if reached {
+		t.Error("Fatal must stop the recovered function")
+	}
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"level":"FATAL"`) || !strings.Contains(string(data), "Fatal log recovered") {
+		t.Errorf("unexpected log output: %s", data)
+	}
+}
+
+func TestSamplingDropsExcessPerLevel(t *testing.T) {
+	out, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	now := time.Unix(1000, 0)
+	logger := NewLogger(DebugLevel, WithSampling(2, time.Second)).(*defaultLogger)
+	logger.out = out
+	logger.sampler.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		logger.Info("info")
+	}
+	logger.WithFields(Field{"k", "v"}).Info("info")
+	logger.Warn("warn")
+	now = now.Add(time.Second)
+	logger.Info("info")
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), `"level":"INFO"`); got != 3 {
+		t.Errorf("got %d INFO entries, want 3", got)
+	}
+	if got := strings.Count(string(data), `"level":"WARN"`); got != 1 {
+		t.Errorf("got %d WARN entries, want 1", got)
+	}
+}
+
+func TestLogFieldsKeepOrder(t *testing.T) {
+	out, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	logger := NewLogger(InfoLevel).(*defaultLogger)
+	logger.out = out
+	logger.WithFields(Field{"zeta", 1}, Field{"alpha", 2}).Info("hello", Field{"mid", "x"}, Field{"zeta", 3})
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(data)
+	prefix := `{"time":"`
+	suffix := `","level":"INFO","message":"hello","zeta":3,"alpha":2,"mid":"x"}` + "\n"
+	if !strings.HasPrefix(line, prefix) || !strings.HasSuffix(line, suffix) {
+		t.Errorf("unexpected log output: %s", line)
+	}
+}
+
+func TestLoggerWithWriterKeepsWriterInWithFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLoggerWithWriter(InfoLevel, &buf)
+	logger.Debug("skipped")
+	logger.WithFields(Field{"component", "test"}).Info("hello")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], `"message":"hello"`) || !strings.Contains(lines[0], `"component":"test"`) {
+		t.Errorf("unexpected log output: %s", lines[0])
+	}
+}
+
+func TestTextFormatSortsFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLoggerWithWriter(InfoLevel, &buf, WithFormat(TextFormat))
+	logger.WithFields(Field{"zeta", 1}).Info("user joined", Field{"name", "Ann Lee"}, Field{"alpha", true})
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	parts := strings.SplitN(line, " ", 2)
+	if _, err := time.Parse(textTimeLayout, parts[0]); err != nil {
+		t.Fatalf("unexpected time prefix in %q: %v", line, err)
+	}
+	if want := `INFO user joined alpha=true name="Ann Lee" zeta=1`; parts[1] != want {
+		t.Errorf("got %q, want %q", parts[1], want)
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Lifecycle управляет корректной остановкой компонентов бота (поллера, вебхук-сервера,
+// метрик, кэша и т.д.). Компоненты регистрируются по мере запуска, а Shutdown
+// останавливает их в обратном порядке, ограничивая время остановки каждого.
+type Lifecycle struct {
+	mu         sync.Mutex
+	components []lifecycleComponent
+	done       bool
+	logger     Logger
+}
+
+// lifecycleComponent – зарегистрированный компонент и параметры его остановки.
+type lifecycleComponent struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+// NewLifecycle создаёт пустой Lifecycle.
+func NewLifecycle(logger Logger) *Lifecycle {
+	return &Lifecycle{logger: logger}
+}
+
+// Register добавляет компонент с функцией остановки stop. Если timeout > 0, контекст,
+// переданный в stop, ограничен этим временем; по его истечении Shutdown переходит
+// к следующему компоненту, даже если stop не вернулась.
+func (l *Lifecycle) Register(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components = append(l.components, lifecycleComponent{name: name, timeout: timeout, stop: stop})
+}
+
+// RegisterCloser добавляет компонент, останавливаемый вызовом Close.
+func (l *Lifecycle) RegisterCloser(name string, timeout time.Duration, closer io.Closer) {
+	l.Register(name, timeout, func(context.Context) error {
+		return closer.Close()
+	})
+}
+
+// Shutdown останавливает компоненты в порядке, обратном регистрации, и возвращает
+// объединённые ошибки остановки. Повторные вызовы ничего не делают и возвращают nil.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	if l.done {
+		l.mu.Unlock()
+		return nil
+	}
+	l.done = true
+	components := l.components
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		l.logger.Info("Stopping component", Field{"component", c.name})
+		if err := l.stop(ctx, c); err != nil {
+			l.logger.Error("Failed to stop component", Field{"component", c.name}, Field{"error", err})
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stop вызывает функцию остановки компонента с учётом его таймаута.
+func (l *Lifecycle) stop(ctx context.Context, c lifecycleComponent) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	result := make(chan error, 1)
+	go func() {
+		var err error
+		WithRecovery(l.logger, func() {
+			err = c.stop(ctx)
+		})
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
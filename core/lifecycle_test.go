@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLifecycleShutdownOrderAndTimeouts(t *testing.T) {
+	l := NewLifecycle(newTestLogger())
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+	l.Register("poller", 0, func(context.Context) error {
+		record("poller")
+		return nil
+	})
+	l.Register("stuck", 10*time.Millisecond, func(ctx context.Context) error {
+		record("stuck")
+		select {}
+	})
+	l.Register("webhook", 0, func(context.Context) error {
+		record("webhook")
+		return errors.New("boom")
+	})
+
+	err := l.Shutdown(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout error for stuck component, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 3 {
+		t.Fatalf("expected 3 components to be stopped, got %v", order)
+	}
+	if order[0] != "webhook" || order[1] != "stuck" || order[2] != "poller" {
+		t.Errorf("components stopped in wrong order: %v", order)
+	}
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Errorf("second Shutdown returned %v", err)
+	}
+}
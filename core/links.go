@@ -0,0 +1,56 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxStartPayloadLength – максимальная длина параметра start в deep-link ссылке.
+const MaxStartPayloadLength = 64
+
+var (
+	// ErrInvalidStartPayload возвращается, если параметр deep-link ссылки пуст, длиннее
+	// MaxStartPayloadLength или содержит символы вне алфавита base64url (A-Z, a-z, 0-9, "_", "-").
+	ErrInvalidStartPayload = errors.New("invalid start payload")
+	// ErrEmptyBotUsername возвращается, если не указано имя бота.
+	ErrEmptyBotUsername = errors.New("bot username is empty")
+)
+
+// StartLink возвращает deep-link ссылку вида https://t.me/<bot>?start=<payload>.
+// При переходе по ней бот получает команду "/start <payload>". Имя бота можно передавать с "@".
+func StartLink(botUsername, payload string) (string, error) {
+	return deepLink(botUsername, "start", payload)
+}
+
+// StartGroupLink возвращает ссылку вида https://t.me/<bot>?startgroup=<payload>,
+// предлагающую добавить бота в группу.
+func StartGroupLink(botUsername, payload string) (string, error) {
+	return deepLink(botUsername, "startgroup", payload)
+}
+
+// deepLink проверяет параметры и собирает ссылку.
+func deepLink(botUsername, param, payload string) (string, error) {
+	botUsername = strings.TrimPrefix(botUsername, "@")
+	if botUsername == "" {
+		return "", ErrEmptyBotUsername
+	}
+	if err := validateStartPayload(payload); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://t.me/%s?%s=%s", botUsername, param, payload), nil
+}
+
+// validateStartPayload проверяет, что payload допустим в deep-link ссылке.
+func validateStartPayload(payload string) error {
+	if payload == "" || len(payload) > MaxStartPayloadLength {
+		return fmt.Errorf("%w: length must be 1-%d, got %d", ErrInvalidStartPayload, MaxStartPayloadLength, len(payload))
+	}
+	for _, r := range payload {
+		isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlnum && r != '_' && r != '-' {
+			return fmt.Errorf("%w: unexpected character %q", ErrInvalidStartPayload, r)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStartLink(t *testing.T) {
+	link, err := StartLink("@my_bot", "ref-42_x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if link != "https://t.me/my_bot?start=ref-42_x" {
+		t.Errorf("got %q", link)
+	}
+	link, err = StartGroupLink("my_bot", "abc")
+	if err != nil || link != "https://t.me/my_bot?startgroup=abc" {
+		t.Errorf("got %q, %v", link, err)
+	}
+}
+
+func TestStartLinkInvalidPayload(t *testing.T) {
+	for _, payload := range []string{"", "has space", "a+b", strings.Repeat("a", MaxStartPayloadLength+1)} {
+		if _, err := StartLink("my_bot", payload); !errors.Is(err, ErrInvalidStartPayload) {
+			t.Errorf("payload %q: got %v, want ErrInvalidStartPayload", payload, err)
+		}
+	}
+	if _, err := StartLink("", "abc"); !errors.Is(err, ErrEmptyBotUsername) {
+		t.Errorf("got %v, want ErrEmptyBotUsername", err)
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidReplyMarkup возвращается методами отправки, если разметка ответа не подходит для Telegram.
+var ErrInvalidReplyMarkup = errors.New("invalid reply markup")
+
+// ReplyMarkup – разметка ответа, умеющая проверять себя перед отправкой. Её реализуют
+// keyboards.InlineKeyboardMarkup, keyboards.ReplyKeyboardMarkup, ReplyKeyboardRemove и ForceReply.
+// Методы с параметром replyMarkup по-прежнему принимают interface{} (например, map),
+// но проверяют разметку через ValidateReplyMarkup до запроса к Telegram.
+type ReplyMarkup interface {
+	// ValidateMarkup возвращает ошибку, если разметка будет отклонена Telegram.
+	ValidateMarkup() error
+}
+
+// ReplyKeyboardRemove убирает reply-клавиатуру у пользователя.
+type ReplyKeyboardRemove struct {
+	RemoveKeyboard bool `json:"remove_keyboard"`
+	Selective      bool `json:"selective,omitempty"`
+}
+
+// ValidateMarkup требует remove_keyboard = true, как того ожидает Telegram.
+func (m ReplyKeyboardRemove) ValidateMarkup() error {
+	if !m.RemoveKeyboard {
+		return fmt.Errorf("%w: remove_keyboard must be true", ErrInvalidReplyMarkup)
+	}
+	return nil
+}
+
+// ForceReply показывает пользователю интерфейс ответа на сообщение бота.
+type ForceReply struct {
+	ForceReply            bool   `json:"force_reply"`
+	InputFieldPlaceholder string `json:"input_field_placeholder,omitempty"`
+	Selective             bool   `json:"selective,omitempty"`
+}
+
+// ValidateMarkup требует force_reply = true и подсказку не длиннее 64 символов.
+func (m ForceReply) ValidateMarkup() error {
+	if !m.ForceReply {
+		return fmt.Errorf("%w: force_reply must be true", ErrInvalidReplyMarkup)
+	}
+	if len([]rune(m.InputFieldPlaceholder)) > 64 {
+		return fmt.Errorf("%w: input_field_placeholder exceeds 64 characters", ErrInvalidReplyMarkup)
+	}
+	return nil
+}
+
+// replyMarkupKeys – поля, по одному из которых Telegram определяет вид разметки.
+var replyMarkupKeys = []string{"inline_keyboard", "keyboard", "remove_keyboard", "force_reply"}
+
+// ValidateReplyMarkup проверяет разметку перед отправкой. Пустая разметка (nil) допустима.
+// Значения, реализующие ReplyMarkup, проверяются своим ValidateMarkup; остальные должны
+// сериализоваться в JSON-объект с полем inline_keyboard, keyboard, remove_keyboard или force_reply.
+// Строка и json.RawMessage считаются уже сериализованной разметкой и проверяются как JSON-объект.
+// Ошибка оборачивает ErrInvalidReplyMarkup.
+func ValidateReplyMarkup(markup interface{}) error {
+	if isNilMarkup(markup) {
+		return nil
+	}
+	if m, ok := markup.(ReplyMarkup); ok {
+		return m.ValidateMarkup()
+	}
+	var data []byte
+	switch m := markup.(type) {
+	case string:
+		// Разметка, уже сериализованная в JSON, передаётся в Telegram как есть.
+		data = []byte(m)
+	case json.RawMessage:
+		data = m
+	default:
+		var err error
+		if data, err = json.Marshal(markup); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReplyMarkup, err)
+		}
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("%w: %T is not a JSON object", ErrInvalidReplyMarkup, markup)
+	}
+	for _, key := range replyMarkupKeys {
+		if _, ok := fields[key]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %T has none of inline_keyboard, keyboard, remove_keyboard, force_reply", ErrInvalidReplyMarkup, markup)
+}
+
+// checkReplyMarkup проверяет разметку метода method и логирует ошибку.
+func (b *botClient) checkReplyMarkup(method string, markup interface{}) error {
+	if err := ValidateReplyMarkup(markup); err != nil {
+		b.logger.Error("Invalid reply markup", Field{"method", method}, Field{"error", err})
+		return err
+	}
+	return nil
+}
+
+// sendOptions собирает opts метода method и проверяет итоговую разметку ответа.
+// Разметка, переданная параметром replyMarkup, заменяет заданную опцией WithReplyMarkup;
+// nil (в том числе nil-указатель) означает, что параметр не задан.
+func (b *botClient) sendOptions(method string, replyMarkup interface{}, opts []SendOption) (SendOptions, error) {
+	options := newSendOptions(opts)
+	if !isNilMarkup(replyMarkup) {
+		options.ReplyMarkup = replyMarkup
+	}
+	if err := b.checkReplyMarkup(method, options.ReplyMarkup); err != nil {
+		return SendOptions{}, err
+	}
+	return options, nil
+}
@@ -1,15 +1,153 @@
 package core
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
 // Update представляет обновление от Telegram.
 type Update struct {
 	UpdateID int      `json:"update_id"`
 	Message  *Message `json:"message,omitempty"`
-	// Можно добавить и другие поля, например, CallbackQuery, если требуется.
+	// CallbackQuery – нажатие кнопки inline-клавиатуры.
+	CallbackQuery           *CallbackQuery           `json:"callback_query,omitempty"`
+	BusinessConnection      *BusinessConnection      `json:"business_connection,omitempty"`
+	BusinessMessage         *Message                 `json:"business_message,omitempty"`
+	EditedBusinessMessage   *Message                 `json:"edited_business_message,omitempty"`
+	DeletedBusinessMessages *BusinessMessagesDeleted `json:"deleted_business_messages,omitempty"`
+	ChatJoinRequest         *ChatJoinRequest         `json:"chat_join_request,omitempty"`
+
+	// raw хранит исходный JSON обновления, полученный от Telegram.
+	raw json.RawMessage
+	// ctx – контекст обработки обновления (correlation ID, отмена и т.д.).
+	ctx context.Context
+}
+
+// UnmarshalJSON разбирает обновление и сохраняет исходный JSON, доступный через Raw.
+func (u *Update) UnmarshalJSON(data []byte) error {
+	// plain не имеет методов, поэтому json.Unmarshal не уйдёт в рекурсию.
+	type plain Update
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*u = Update(p)
+	u.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// EffectiveChat возвращает чат, к которому относится обновление, или nil,
+// если обновление не связано с конкретным чатом.
+func (u Update) EffectiveChat() *Chat {
+	switch {
+	case u.Message != nil:
+		return &u.Message.Chat
+	case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+		return &u.CallbackQuery.Message.Chat
+	case u.BusinessMessage != nil:
+		return &u.BusinessMessage.Chat
+	case u.EditedBusinessMessage != nil:
+		return &u.EditedBusinessMessage.Chat
+	case u.DeletedBusinessMessages != nil:
+		return &u.DeletedBusinessMessages.Chat
+	case u.ChatJoinRequest != nil:
+		return &u.ChatJoinRequest.Chat
+	default:
+		return nil
+	}
+}
+
+// EffectiveUser возвращает отправителя обновления или nil, если он неизвестен.
+func (u Update) EffectiveUser() *User {
+	switch {
+	case u.Message != nil:
+		return u.Message.From
+	case u.CallbackQuery != nil:
+		return &u.CallbackQuery.From
+	case u.BusinessMessage != nil:
+		return u.BusinessMessage.From
+	case u.EditedBusinessMessage != nil:
+		return u.EditedBusinessMessage.From
+	case u.BusinessConnection != nil:
+		return &u.BusinessConnection.User
+	case u.ChatJoinRequest != nil:
+		return &u.ChatJoinRequest.From
+	default:
+		return nil
+	}
+}
+
+// Context возвращает контекст обработки обновления. Поллер и вебхук кладут в него
+// correlation ID и отменяют его при остановке. Если контекст не задан, возвращается context.Background().
+func (u Update) Context() context.Context {
+	if u.ctx != nil {
+		return u.ctx
+	}
+	return context.Background()
+}
+
+// WithContext возвращает копию обновления с указанным контекстом.
+func (u Update) WithContext(ctx context.Context) Update {
+	if ctx == nil {
+		panic("core: nil context")
+	}
+	u.ctx = ctx
+	return u
+}
+
+// Raw возвращает исходный JSON обновления (при получении через поллинг или вебхук).
+// Позволяет разобрать поля, которые ещё не описаны в структурах библиотеки.
+// Для обновлений, созданных вручную, возвращает nil.
+func (u Update) Raw() json.RawMessage {
+	return u.raw
+}
+
+// UpdateType обозначает вид обновления Telegram (совпадает с именем поля в JSON).
+type UpdateType string
+
+const (
+	UpdateTypeUnknown                 UpdateType = ""
+	UpdateTypeMessage                 UpdateType = "message"
+	UpdateTypeCallbackQuery           UpdateType = "callback_query"
+	UpdateTypeBusinessConnection      UpdateType = "business_connection"
+	UpdateTypeBusinessMessage         UpdateType = "business_message"
+	UpdateTypeEditedBusinessMessage   UpdateType = "edited_business_message"
+	UpdateTypeDeletedBusinessMessages UpdateType = "deleted_business_messages"
+	UpdateTypeChatJoinRequest         UpdateType = "chat_join_request"
+)
+
+// Type возвращает вид обновления по заполненному полю.
+// Telegram присылает в одном обновлении ровно одно из полей.
+func (u Update) Type() UpdateType {
+	switch {
+	case u.Message != nil:
+		return UpdateTypeMessage
+	case u.CallbackQuery != nil:
+		return UpdateTypeCallbackQuery
+	case u.BusinessConnection != nil:
+		return UpdateTypeBusinessConnection
+	case u.BusinessMessage != nil:
+		return UpdateTypeBusinessMessage
+	case u.EditedBusinessMessage != nil:
+		return UpdateTypeEditedBusinessMessage
+	case u.DeletedBusinessMessages != nil:
+		return UpdateTypeDeletedBusinessMessages
+	case u.ChatJoinRequest != nil:
+		return UpdateTypeChatJoinRequest
+	default:
+		return UpdateTypeUnknown
+	}
 }
 
 // Message представляет сообщение Telegram.
 type Message struct {
 	MessageID int    `json:"message_id"`
+	From      *User  `json:"from,omitempty"`
+	Date      int64  `json:"date"`
 	Chat      Chat   `json:"chat"`
 	Text      string `json:"text,omitempty"`
 	// Дополнительные поля, если необходимо.
@@ -18,8 +156,44 @@ type Message struct {
 	Contact  *Contact  `json:"contact,omitempty"`
 	Location *Location `json:"location,omitempty"`
 	// Можно добавить Document, Animation и т.д.
-	Document *Document `json:"document,omitempty"`
+	Document  *Document  `json:"document,omitempty"`
 	Animation *Animation `json:"animation,omitempty"`
+	// BusinessConnectionID заполняется для сообщений, полученных через бизнес-аккаунт.
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	// ForwardOrigin заполняется, если сообщение было переслано.
+	ForwardOrigin *ForwardOrigin `json:"forward_origin,omitempty"`
+	// Entities – специальные сущности текста (ссылки, упоминания, кастомные эмодзи и т.д.).
+	Entities []MessageEntity `json:"entities,omitempty"`
+	// Caption и CaptionEntities – подпись к медиа и её сущности.
+	Caption         string          `json:"caption,omitempty"`
+	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
+	Sticker         *Sticker        `json:"sticker,omitempty"`
+	Game            *Game           `json:"game,omitempty"`
+	// UsersShared и ChatShared – служебные сообщения о выборе пользователей или чата
+	// кнопками request_users и request_chat reply-клавиатуры.
+	UsersShared *UsersShared `json:"users_shared,omitempty"`
+	ChatShared  *ChatShared  `json:"chat_shared,omitempty"`
+	// Giveaway – сообщение с розыгрышем (в канале или пересланное).
+	// GiveawayCreated, GiveawayWinners и GiveawayCompleted – служебные сообщения
+	// о запуске розыгрыша, публикации победителей и его завершении.
+	Giveaway          *Giveaway          `json:"giveaway,omitempty"`
+	GiveawayCreated   *GiveawayCreated   `json:"giveaway_created,omitempty"`
+	GiveawayWinners   *GiveawayWinners   `json:"giveaway_winners,omitempty"`
+	GiveawayCompleted *GiveawayCompleted `json:"giveaway_completed,omitempty"`
+}
+
+// CustomEmojiIDs возвращает идентификаторы кастомных эмодзи из текста и подписи сообщения
+// в порядке их появления. Стикеры по ним можно получить через BotAPI.GetCustomEmojiStickers.
+func (m Message) CustomEmojiIDs() []string {
+	var ids []string
+	for _, entities := range [][]MessageEntity{m.Entities, m.CaptionEntities} {
+		for _, e := range entities {
+			if e.Type == MessageEntityCustomEmoji && e.CustomEmojiID != "" {
+				ids = append(ids, e.CustomEmojiID)
+			}
+		}
+	}
+	return ids
 }
 
 // Chat представляет чат Telegram.
@@ -31,9 +205,26 @@ type Chat struct {
 	Username  string `json:"username,omitempty"`
 	FirstName string `json:"first_name,omitempty"`
 	LastName  string `json:"last_name,omitempty"`
+	// LinkedChatID – ID связанного чата: группы обсуждений для канала или канала для группы.
+	// Возвращается только getChat.
+	LinkedChatID int64 `json:"linked_chat_id,omitempty"`
+	// SlowModeDelay – минимальный интервал в секундах между сообщениями одного пользователя
+	// в супергруппе. Возвращается только getChat.
+	SlowModeDelay int `json:"slow_mode_delay,omitempty"`
+	// Photo – фотография (аватар) чата. Возвращается только getChat.
+	Photo *ChatPhoto `json:"photo,omitempty"`
 	// Дополнительные поля можно добавить по необходимости.
 }
 
+// ChatPhoto представляет фотографию чата в двух размерах: 160x160 (small) и 640x640 (big).
+// Идентификаторы пригодны только для скачивания и действуют, пока фотография не изменится.
+type ChatPhoto struct {
+	SmallFileID       string `json:"small_file_id"`
+	SmallFileUniqueID string `json:"small_file_unique_id"`
+	BigFileID         string `json:"big_file_id"`
+	BigFileUniqueID   string `json:"big_file_unique_id"`
+}
+
 // Video представляет видео-сообщение.
 type Video struct {
 	FileID   string `json:"file_id"`
@@ -46,12 +237,12 @@ type Video struct {
 
 // Audio представляет аудиосообщение.
 type Audio struct {
-	FileID   string `json:"file_id"`
-	Duration int    `json:"duration"`
+	FileID    string `json:"file_id"`
+	Duration  int    `json:"duration"`
 	Performer string `json:"performer,omitempty"`
-	Title    string `json:"title,omitempty"`
-	MimeType string `json:"mime_type,omitempty"`
-	FileSize int    `json:"file_size,omitempty"`
+	Title     string `json:"title,omitempty"`
+	MimeType  string `json:"mime_type,omitempty"`
+	FileSize  int    `json:"file_size,omitempty"`
 }
 
 // Contact представляет контакт пользователя.
@@ -86,6 +277,190 @@ type Animation struct {
 	FileSize int    `json:"file_size,omitempty"`
 }
 
+// Message entity types used in MessageEntity.Type (not exhaustive).
+const (
+	MessageEntityMention     = "mention"
+	MessageEntityBotCommand  = "bot_command"
+	MessageEntityURL         = "url"
+	MessageEntityTextLink    = "text_link"
+	MessageEntityTextMention = "text_mention"
+	MessageEntityCustomEmoji = "custom_emoji"
+)
+
+// MessageEntity represents a special entity in a text message, such as a link or a custom emoji.
+// Offset and Length are measured in UTF-16 code units; use Text to extract the entity's substring.
+type MessageEntity struct {
+	Type          string `json:"type"`
+	Offset        int    `json:"offset"`
+	Length        int    `json:"length"`
+	URL           string `json:"url,omitempty"`
+	User          *User  `json:"user,omitempty"`
+	Language      string `json:"language,omitempty"`
+	CustomEmojiID string `json:"custom_emoji_id,omitempty"`
+}
+
+// Text returns the part of text covered by the entity, or "" if the entity is out of range.
+func (e MessageEntity) Text(text string) string {
+	units := utf16.Encode([]rune(text))
+	end := e.Offset + e.Length
+	if e.Offset < 0 || e.Length < 0 || end > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[e.Offset:end]))
+}
+
+// Sticker represents a sticker, including custom emoji stickers (Type "custom_emoji").
+type Sticker struct {
+	FileID        string `json:"file_id"`
+	FileUniqueID  string `json:"file_unique_id"`
+	Type          string `json:"type"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	IsAnimated    bool   `json:"is_animated"`
+	IsVideo       bool   `json:"is_video"`
+	Emoji         string `json:"emoji,omitempty"`
+	SetName       string `json:"set_name,omitempty"`
+	CustomEmojiID string `json:"custom_emoji_id,omitempty"`
+	FileSize      int    `json:"file_size,omitempty"`
+}
+
+// LinkPreviewOptions describes how link previews are generated for a message.
+type LinkPreviewOptions struct {
+	IsDisabled       bool   `json:"is_disabled,omitempty"`
+	URL              string `json:"url,omitempty"`
+	PreferSmallMedia bool   `json:"prefer_small_media,omitempty"`
+	PreferLargeMedia bool   `json:"prefer_large_media,omitempty"`
+	ShowAboveText    bool   `json:"show_above_text,omitempty"`
+}
+
+// PhotoSize represents one size of a photo or a file/sticker thumbnail.
+type PhotoSize struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FileSize     int    `json:"file_size,omitempty"`
+}
+
+// BotCommand describes a bot command shown in the Telegram command menu.
+// Command is given without the leading slash.
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// UsersShared is a service message about users shared with the bot via a request_users button.
+type UsersShared struct {
+	// RequestID matches the request_id of the keyboard button.
+	RequestID int          `json:"request_id"`
+	Users     []SharedUser `json:"users"`
+}
+
+// SharedUser describes a user shared with the bot. Name, username and photo are set
+// only if the button requested them.
+type SharedUser struct {
+	UserID    int64       `json:"user_id"`
+	FirstName string      `json:"first_name,omitempty"`
+	LastName  string      `json:"last_name,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	Photo     []PhotoSize `json:"photo,omitempty"`
+}
+
+// ChatShared is a service message about a chat shared with the bot via a request_chat button.
+type ChatShared struct {
+	// RequestID matches the request_id of the keyboard button.
+	RequestID int         `json:"request_id"`
+	ChatID    int64       `json:"chat_id"`
+	Title     string      `json:"title,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	Photo     []PhotoSize `json:"photo,omitempty"`
+}
+
+// GiveawayCreated is a service message about the creation of a scheduled giveaway.
+type GiveawayCreated struct {
+	// PrizeStarCount is set only for Telegram Star giveaways.
+	PrizeStarCount int `json:"prize_star_count,omitempty"`
+}
+
+// Giveaway is a message about a scheduled giveaway.
+type Giveaway struct {
+	// Chats the user must subscribe to in order to take part.
+	Chats []Chat `json:"chats"`
+	// WinnersSelectionDate is a Unix time when the winners will be selected.
+	WinnersSelectionDate int64  `json:"winners_selection_date"`
+	WinnerCount          int    `json:"winner_count"`
+	OnlyNewMembers       bool   `json:"only_new_members,omitempty"`
+	HasPublicWinners     bool   `json:"has_public_winners,omitempty"`
+	PrizeDescription     string `json:"prize_description,omitempty"`
+	// CountryCodes lists two-letter ISO 3166-1 alpha-2 codes of eligible countries;
+	// empty means users from all countries can participate.
+	CountryCodes                  []string `json:"country_codes,omitempty"`
+	PrizeStarCount                int      `json:"prize_star_count,omitempty"`
+	PremiumSubscriptionMonthCount int      `json:"premium_subscription_month_count,omitempty"`
+}
+
+// GiveawayWinners is a message about the completion of a giveaway with public winners.
+type GiveawayWinners struct {
+	// Chat that created the giveaway and GiveawayMessageID of its giveaway message.
+	Chat                 Chat  `json:"chat"`
+	GiveawayMessageID    int   `json:"giveaway_message_id"`
+	WinnersSelectionDate int64 `json:"winners_selection_date"`
+	WinnerCount          int   `json:"winner_count"`
+	// Winners holds up to 100 of the winners.
+	Winners                       []User `json:"winners"`
+	AdditionalChatCount           int    `json:"additional_chat_count,omitempty"`
+	PrizeStarCount                int    `json:"prize_star_count,omitempty"`
+	PremiumSubscriptionMonthCount int    `json:"premium_subscription_month_count,omitempty"`
+	UnclaimedPrizeCount           int    `json:"unclaimed_prize_count,omitempty"`
+	OnlyNewMembers                bool   `json:"only_new_members,omitempty"`
+	WasRefunded                   bool   `json:"was_refunded,omitempty"`
+	PrizeDescription              string `json:"prize_description,omitempty"`
+}
+
+// GiveawayCompleted is a service message about the completion of a giveaway without public winners.
+type GiveawayCompleted struct {
+	WinnerCount         int `json:"winner_count"`
+	UnclaimedPrizeCount int `json:"unclaimed_prize_count,omitempty"`
+	// GiveawayMessage is the original giveaway message, if it wasn't deleted.
+	GiveawayMessage *Message `json:"giveaway_message,omitempty"`
+	IsStarGiveaway  bool     `json:"is_star_giveaway,omitempty"`
+}
+
+// Game represents an HTML5 game created via @BotFather.
+type Game struct {
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	Photo        []PhotoSize     `json:"photo"`
+	Text         string          `json:"text,omitempty"`
+	TextEntities []MessageEntity `json:"text_entities,omitempty"`
+	Animation    *Animation      `json:"animation,omitempty"`
+}
+
+// GameHighScore represents one row of a game's high scores table.
+type GameHighScore struct {
+	Position int  `json:"position"`
+	User     User `json:"user"`
+	Score    int  `json:"score"`
+}
+
+// GameMessageRef identifies the message with a game: either ChatID and MessageID,
+// or InlineMessageID for games sent via inline mode.
+type GameMessageRef struct {
+	ChatID          int64  `json:"chat_id,omitempty"`
+	MessageID       int    `json:"message_id,omitempty"`
+	InlineMessageID string `json:"inline_message_id,omitempty"`
+}
+
+// apply adds the message reference to a request payload.
+func (r GameMessageRef) apply(payload map[string]interface{}) {
+	if r.InlineMessageID != "" {
+		payload["inline_message_id"] = r.InlineMessageID
+		return
+	}
+	payload["chat_id"] = r.ChatID
+	payload["message_id"] = r.MessageID
+}
+
 // User represents a Telegram user (including the bot itself).
 type User struct {
 	ID           int    `json:"id"`
@@ -94,4 +469,163 @@ type User struct {
 	LastName     string `json:"last_name,omitempty"`
 	Username     string `json:"username,omitempty"`
 	LanguageCode string `json:"language_code,omitempty"`
+	IsPremium    bool   `json:"is_premium,omitempty"`
+
+	// The following fields are returned only by getMe and describe the bot's settings.
+
+	// CanJoinGroups reports whether the bot can be invited to groups.
+	CanJoinGroups bool `json:"can_join_groups,omitempty"`
+	// CanReadAllGroupMessages reports whether privacy mode is disabled, so the bot receives all group messages.
+	CanReadAllGroupMessages bool `json:"can_read_all_group_messages,omitempty"`
+	// SupportsInlineQueries reports whether the bot supports inline queries.
+	SupportsInlineQueries bool `json:"supports_inline_queries,omitempty"`
+	// CanConnectToBusiness reports whether the bot can be connected to a Telegram Business account.
+	CanConnectToBusiness bool `json:"can_connect_to_business,omitempty"`
+	// HasMainWebApp reports whether the bot has a main Web App.
+	HasMainWebApp bool `json:"has_main_web_app,omitempty"`
+}
+
+// BusinessConnection describes the connection of the bot with a business account.
+type BusinessConnection struct {
+	ID         string `json:"id"`
+	User       User   `json:"user"`
+	UserChatID int64  `json:"user_chat_id"`
+	Date       int    `json:"date"`
+	CanReply   bool   `json:"can_reply,omitempty"`
+	IsEnabled  bool   `json:"is_enabled"`
+}
+
+// BusinessMessagesDeleted is received when messages are deleted from a connected business account.
+type BusinessMessagesDeleted struct {
+	BusinessConnectionID string `json:"business_connection_id"`
+	Chat                 Chat   `json:"chat"`
+	MessageIDs           []int  `json:"message_ids"`
+}
+
+// CallbackQuery represents a press of an inline keyboard button.
+// Message is the message the button was attached to; it is nil for buttons of messages
+// sent via inline mode, which are identified by InlineMessageID instead. If the message
+// is too old to be accessible, only its MessageID and Chat are set (its Date is 0).
+type CallbackQuery struct {
+	ID              string   `json:"id"`
+	From            User     `json:"from"`
+	Message         *Message `json:"message,omitempty"`
+	InlineMessageID string   `json:"inline_message_id,omitempty"`
+	ChatInstance    string   `json:"chat_instance"`
+	Data            string   `json:"data,omitempty"`
+	GameShortName   string   `json:"game_short_name,omitempty"`
+}
+
+// ChatJoinRequest represents a request to join a chat via an invite link that requires approval.
+// Approve or decline it with BotAPI.ApproveChatJoinRequest / DeclineChatJoinRequest.
+type ChatJoinRequest struct {
+	Chat Chat `json:"chat"`
+	From User `json:"from"`
+	// UserChatID is the private chat with the user, usable for 5 minutes to contact them.
+	UserChatID int64           `json:"user_chat_id"`
+	Date       int64           `json:"date"`
+	Bio        string          `json:"bio,omitempty"`
+	InviteLink *ChatInviteLink `json:"invite_link,omitempty"`
+}
+
+// ChatInviteLink represents an invite link for a chat.
+type ChatInviteLink struct {
+	InviteLink         string `json:"invite_link"`
+	Creator            User   `json:"creator"`
+	CreatesJoinRequest bool   `json:"creates_join_request"`
+	IsPrimary          bool   `json:"is_primary"`
+	IsRevoked          bool   `json:"is_revoked"`
+	Name               string `json:"name,omitempty"`
+}
+
+// Forward origin types reported in ForwardOrigin.Type.
+const (
+	ForwardOriginUser       = "user"
+	ForwardOriginHiddenUser = "hidden_user"
+	ForwardOriginChat       = "chat"
+	ForwardOriginChannel    = "channel"
+)
+
+// ForwardOrigin describes where a forwarded message originally came from
+// (the Bot API MessageOrigin object). Which fields are set depends on Type:
+// SenderUser for "user", SenderUserName for "hidden_user", SenderChat for "chat",
+// and Chat with MessageID for "channel".
+type ForwardOrigin struct {
+	Type            string `json:"type"`
+	Date            int    `json:"date"`
+	SenderUser      *User  `json:"sender_user,omitempty"`
+	SenderUserName  string `json:"sender_user_name,omitempty"`
+	SenderChat      *Chat  `json:"sender_chat,omitempty"`
+	Chat            *Chat  `json:"chat,omitempty"`
+	MessageID       int    `json:"message_id,omitempty"`
+	AuthorSignature string `json:"author_signature,omitempty"`
+}
+
+// InputMedia describes a single item of a media group (album).
+// Type is one of "photo", "video", "audio" or "document"; Media is a URL or a file_id.
+type InputMedia struct {
+	Type       string `json:"type"`
+	Media      string `json:"media"`
+	Caption    string `json:"caption,omitempty"`
+	ParseMode  string `json:"parse_mode,omitempty"`
+	HasSpoiler bool   `json:"has_spoiler,omitempty"`
+}
+
+// ChatID identifies a chat either by its numeric ID or by the public
+// username of a channel or supergroup ("@channelusername").
+// It serializes to a JSON number or string respectively, as the Bot API expects for chat_id.
+// BotAPI send, edit and chat management methods take a ChatID, so a channel can be
+// addressed as ChatIDFromUsername("@mychannel") without resolving its numeric ID first.
+type ChatID struct {
+	ID       int64
+	Username string
+}
+
+// ChatIDFromInt returns a ChatID for a numeric chat identifier.
+func ChatIDFromInt(id int64) ChatID {
+	return ChatID{ID: id}
+}
+
+// ChatIDFromUsername returns a ChatID for a public username; the leading "@" is optional.
+func ChatIDFromUsername(username string) ChatID {
+	if username != "" && !strings.HasPrefix(username, "@") {
+		username = "@" + username
+	}
+	return ChatID{Username: username}
+}
+
+// IsZero reports whether neither the numeric ID nor the username is set.
+func (c ChatID) IsZero() bool {
+	return c.ID == 0 && c.Username == ""
+}
+
+// String returns the username if set, otherwise the numeric ID.
+func (c ChatID) String() string {
+	if c.Username != "" {
+		return c.Username
+	}
+	return strconv.FormatInt(c.ID, 10)
+}
+
+// MarshalJSON encodes the username as a JSON string or the ID as a JSON number.
+func (c ChatID) MarshalJSON() ([]byte, error) {
+	if c.Username != "" {
+		return json.Marshal(c.Username)
+	}
+	return json.Marshal(c.ID)
+}
+
+// UnmarshalJSON accepts either a JSON number or a JSON string.
+func (c *ChatID) UnmarshalJSON(data []byte) error {
+	var id int64
+	if err := json.Unmarshal(data, &id); err == nil {
+		*c = ChatID{ID: id}
+		return nil
+	}
+	var username string
+	if err := json.Unmarshal(data, &username); err != nil {
+		return errors.New("chat id must be a number or a string")
+	}
+	*c = ChatIDFromUsername(username)
+	return nil
 }
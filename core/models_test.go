@@ -0,0 +1,106 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChatIDMarshalJSON(t *testing.T) {
+	cases := []struct {
+		chat ChatID
+		want string
+	}{
+		{ChatIDFromInt(-100123), `-100123`},
+		{ChatIDFromUsername("mychannel"), `"@mychannel"`},
+		{ChatIDFromUsername("@mychannel"), `"@mychannel"`},
+	}
+	for _, c := range cases {
+		got, err := json.Marshal(c.chat)
+		if err != nil {
+			t.Fatalf("marshal %v: %v", c.chat, err)
+		}
+		if string(got) != c.want {
+			t.Errorf("marshal %v = %s, want %s", c.chat, got, c.want)
+		}
+	}
+}
+
+func TestChatIDUnmarshalJSON(t *testing.T) {
+	var chat ChatID
+	if err := json.Unmarshal([]byte(`"@mychannel"`), &chat); err != nil || chat.Username != "@mychannel" {
+		t.Fatalf("unexpected result: %+v, %v", chat, err)
+	}
+	if err := json.Unmarshal([]byte(`42`), &chat); err != nil || chat.ID != 42 || chat.Username != "" {
+		t.Fatalf("unexpected result: %+v, %v", chat, err)
+	}
+}
+
+func TestCustomEmojiEntities(t *testing.T) {
+	data := `{"message_id":1,"chat":{"id":1},"text":"hi 😀 x🎉",
+		"entities":[{"type":"custom_emoji","offset":3,"length":2,"custom_emoji_id":"111"},
+		            {"type":"custom_emoji","offset":7,"length":2,"custom_emoji_id":"222"}]}`
+	var msg Message
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatal(err)
+	}
+	ids := msg.CustomEmojiIDs()
+	if len(ids) != 2 || ids[0] != "111" || ids[1] != "222" {
+		t.Errorf("got ids %v", ids)
+	}
+	if got := msg.Entities[0].Text(msg.Text); got != "😀" {
+		t.Errorf("first entity text = %q", got)
+	}
+	if got := msg.Entities[1].Text(msg.Text); got != "🎉" {
+		t.Errorf("second entity text = %q", got)
+	}
+}
+
+func TestCallbackQueryMessage(t *testing.T) {
+	data := `{"update_id":9,"callback_query":{"id":"cb1","from":{"id":5,"is_bot":false,"first_name":"A"},
+		"chat_instance":"ci","data":"like",
+		"message":{"message_id":77,"date":1700000000,"chat":{"id":-100},"text":"Vote"}}}`
+	var update Update
+	if err := json.Unmarshal([]byte(data), &update); err != nil {
+		t.Fatal(err)
+	}
+	cb := update.CallbackQuery
+	if cb == nil || cb.Message == nil || cb.Message.MessageID != 77 || cb.Message.Text != "Vote" {
+		t.Fatalf("unexpected callback query: %+v", cb)
+	}
+	if update.Type() != UpdateTypeCallbackQuery || update.EffectiveChat().ID != -100 || update.EffectiveUser().ID != 5 {
+		t.Errorf("unexpected type/chat/user: %q %+v %+v", update.Type(), update.EffectiveChat(), update.EffectiveUser())
+	}
+}
+
+func TestMessageSharedChatAndUsers(t *testing.T) {
+	data := `{"message_id":1,"chat":{"id":5},
+		"chat_shared":{"request_id":1,"chat_id":-1001,"title":"News"},
+		"users_shared":{"request_id":2,"users":[{"user_id":7,"username":"alice"}]}}`
+	var msg Message
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.ChatShared == nil || msg.ChatShared.RequestID != 1 || msg.ChatShared.ChatID != -1001 || msg.ChatShared.Title != "News" {
+		t.Errorf("unexpected chat_shared: %+v", msg.ChatShared)
+	}
+	if msg.UsersShared == nil || len(msg.UsersShared.Users) != 1 || msg.UsersShared.Users[0].Username != "alice" {
+		t.Errorf("unexpected users_shared: %+v", msg.UsersShared)
+	}
+}
+
+func TestMessageGiveaway(t *testing.T) {
+	data := `{"message_id":1,"chat":{"id":-1001,"type":"channel"},
+		"giveaway":{"chats":[{"id":-1001}],"winners_selection_date":1700000000,"winner_count":3,"country_codes":["DE"]},
+		"giveaway_winners":{"chat":{"id":-1001},"giveaway_message_id":7,"winners_selection_date":1700000000,
+			"winner_count":2,"winners":[{"id":5,"first_name":"Ann"},{"id":6,"first_name":"Bob"}],"prize_star_count":500}}`
+	var msg Message
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if g := msg.Giveaway; g == nil || len(g.Chats) != 1 || g.WinnerCount != 3 || g.CountryCodes[0] != "DE" {
+		t.Errorf("unexpected giveaway: %+v", msg.Giveaway)
+	}
+	if w := msg.GiveawayWinners; w == nil || w.GiveawayMessageID != 7 || len(w.Winners) != 2 || w.PrizeStarCount != 500 {
+		t.Errorf("unexpected giveaway_winners: %+v", msg.GiveawayWinners)
+	}
+}
@@ -0,0 +1,219 @@
+package core
+
+// SendOptions содержит необязательные параметры методов отправки.
+// Все методы отправки и редактирования принимают их через variadic-параметр ...SendOption,
+// поэтому новые параметры Telegram добавляются сюда без изменения сигнатур методов.
+// Незаданные (нулевые) значения не попадают в запрос к Telegram.
+type SendOptions struct {
+	// ParseMode – режим разметки текста: "HTML", "MarkdownV2" или "Markdown".
+	ParseMode string
+	// DisableNotification отправляет сообщение без звука.
+	DisableNotification bool
+	// ReplyMarkup – клавиатура или другая разметка ответа.
+	ReplyMarkup interface{}
+	// HasSpoiler скрывает фото, видео или анимацию под спойлером.
+	HasSpoiler bool
+	// MessageEffectID – идентификатор эффекта сообщения (только для личных чатов).
+	MessageEffectID string
+	// ProtectContent запрещает пересылку и сохранение отправленного сообщения.
+	ProtectContent bool
+	// Width, Height и Duration (в секундах) – размеры и длительность анимации или видео.
+	Width    int
+	Height   int
+	Duration int
+	// ReplyToMessageID – идентификатор сообщения, на которое отправляется ответ.
+	ReplyToMessageID int
+	// Entities – сущности текста или подписи, например кастомные эмодзи; используются вместо ParseMode.
+	// Для методов без текста (SendPhoto, SendDocument, SendAnimation) передаются как caption_entities.
+	Entities []MessageEntity
+	// MessageThreadID – идентификатор темы (топика) форума или супергруппы.
+	MessageThreadID int
+	// LinkPreviewOptions управляет предпросмотром ссылок в тексте.
+	LinkPreviewOptions *LinkPreviewOptions
+	// BusinessConnectionID – идентификатор бизнес-подключения, от имени которого отправляется сообщение.
+	BusinessConnectionID string
+	// AllowPaidBroadcast разрешает платную рассылку сверх бесплатного лимита (за Telegram Stars).
+	AllowPaidBroadcast bool
+}
+
+// Режимы разметки для WithParseMode и WithDefaultParseMode.
+const (
+	ParseModeHTML       = "HTML"
+	ParseModeMarkdownV2 = "MarkdownV2"
+	// ParseModeMarkdown – устаревший режим, оставленный Telegram для совместимости.
+	ParseModeMarkdown = "Markdown"
+)
+
+// SendOption изменяет SendOptions.
+type SendOption func(*SendOptions)
+
+// WithParseMode задаёт режим разметки текста или подписи (ParseModeHTML, ParseModeMarkdownV2).
+func WithParseMode(mode string) SendOption {
+	return func(o *SendOptions) {
+		o.ParseMode = mode
+	}
+}
+
+// WithDisableNotification отключает звуковое уведомление о сообщении.
+func WithDisableNotification() SendOption {
+	return func(o *SendOptions) {
+		o.DisableNotification = true
+	}
+}
+
+// WithReplyMarkup прикрепляет к сообщению клавиатуру или другую разметку. В методах
+// с параметром replyMarkup (SendPhoto, EditMessageText и др.) непустой параметр имеет приоритет.
+func WithReplyMarkup(markup interface{}) SendOption {
+	return func(o *SendOptions) {
+		o.ReplyMarkup = markup
+	}
+}
+
+// WithSpoiler скрывает отправляемое медиа под спойлером.
+func WithSpoiler() SendOption {
+	return func(o *SendOptions) {
+		o.HasSpoiler = true
+	}
+}
+
+// WithMessageEffect добавляет к сообщению эффект с указанным идентификатором.
+func WithMessageEffect(effectID string) SendOption {
+	return func(o *SendOptions) {
+		o.MessageEffectID = effectID
+	}
+}
+
+// WithProtectContent запрещает пересылку и сохранение отправляемого сообщения.
+func WithProtectContent() SendOption {
+	return func(o *SendOptions) {
+		o.ProtectContent = true
+	}
+}
+
+// WithDimensions задаёт ширину и высоту отправляемой анимации или видео.
+func WithDimensions(width, height int) SendOption {
+	return func(o *SendOptions) {
+		o.Width = width
+		o.Height = height
+	}
+}
+
+// WithDuration задаёт длительность отправляемой анимации или видео в секундах.
+func WithDuration(seconds int) SendOption {
+	return func(o *SendOptions) {
+		o.Duration = seconds
+	}
+}
+
+// WithReplyTo отправляет сообщение как ответ на сообщение messageID того же чата.
+func WithReplyTo(messageID int) SendOption {
+	return func(o *SendOptions) {
+		o.ReplyToMessageID = messageID
+	}
+}
+
+// WithEntities задаёт сущности отправляемого текста или подписи к медиа
+// (например, для повторной отправки кастомных эмодзи).
+func WithEntities(entities []MessageEntity) SendOption {
+	return func(o *SendOptions) {
+		o.Entities = entities
+	}
+}
+
+// WithMessageThreadID отправляет сообщение в тему форума с указанным идентификатором.
+func WithMessageThreadID(threadID int) SendOption {
+	return func(o *SendOptions) {
+		o.MessageThreadID = threadID
+	}
+}
+
+// WithLinkPreviewOptions задаёт параметры предпросмотра ссылок.
+func WithLinkPreviewOptions(options LinkPreviewOptions) SendOption {
+	return func(o *SendOptions) {
+		o.LinkPreviewOptions = &options
+	}
+}
+
+// WithoutLinkPreview отключает предпросмотр ссылок.
+func WithoutLinkPreview() SendOption {
+	return WithLinkPreviewOptions(LinkPreviewOptions{IsDisabled: true})
+}
+
+// WithBusinessConnection отправляет сообщение от имени бизнес-аккаунта через подключение
+// с указанным идентификатором (например, Message.BusinessConnectionID входящего сообщения).
+func WithBusinessConnection(connectionID string) SendOption {
+	return func(o *SendOptions) {
+		o.BusinessConnectionID = connectionID
+	}
+}
+
+// WithAllowPaidBroadcast разрешает отправку сверх бесплатного лимита в 30 сообщений в секунду
+// за счёт Telegram Stars бота (до 1000 сообщений в секунду). При использовании общего RateLimiter
+// его частоту нужно поднять, например NewRateLimiter(PaidBroadcastRequestsPerSecond).
+func WithAllowPaidBroadcast() SendOption {
+	return func(o *SendOptions) {
+		o.AllowPaidBroadcast = true
+	}
+}
+
+// newSendOptions применяет opts к пустым SendOptions.
+func newSendOptions(opts []SendOption) SendOptions {
+	var o SendOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// apply добавляет заданные параметры в payload запроса.
+func (o SendOptions) apply(payload map[string]interface{}) {
+	if o.ParseMode != "" {
+		payload["parse_mode"] = o.ParseMode
+	}
+	if o.DisableNotification {
+		payload["disable_notification"] = true
+	}
+	if !isNilMarkup(o.ReplyMarkup) {
+		payload["reply_markup"] = o.ReplyMarkup
+	}
+	if o.HasSpoiler {
+		payload["has_spoiler"] = true
+	}
+	if o.MessageEffectID != "" {
+		payload["message_effect_id"] = o.MessageEffectID
+	}
+	if o.ProtectContent {
+		payload["protect_content"] = true
+	}
+	if o.Width > 0 {
+		payload["width"] = o.Width
+	}
+	if o.Height > 0 {
+		payload["height"] = o.Height
+	}
+	if o.Duration > 0 {
+		payload["duration"] = o.Duration
+	}
+	if len(o.Entities) > 0 {
+		if _, isText := payload["text"]; isText {
+			payload["entities"] = o.Entities
+		} else {
+			payload["caption_entities"] = o.Entities
+		}
+	}
+	if o.MessageThreadID != 0 {
+		payload["message_thread_id"] = o.MessageThreadID
+	}
+	if o.LinkPreviewOptions != nil {
+		payload["link_preview_options"] = o.LinkPreviewOptions
+	}
+	if o.BusinessConnectionID != "" {
+		payload["business_connection_id"] = o.BusinessConnectionID
+	}
+	if o.AllowPaidBroadcast {
+		payload["allow_paid_broadcast"] = true
+	}
+	if o.ReplyToMessageID != 0 {
+		payload["reply_parameters"] = map[string]interface{}{"message_id": o.ReplyToMessageID}
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"errors"
+	"sync"
+)
+
+// orderedQueueSize – ёмкость очереди одного обработчика OrderedDispatcher.
+const orderedQueueSize = 64
+
+// ErrDispatcherClosed возвращается при попытке передать обновление закрытому диспетчеру.
+var ErrDispatcherClosed = errors.New("dispatcher is closed")
+
+// OrderedDispatcher обрабатывает обновления параллельно, сохраняя порядок внутри каждого чата.
+// Обновления распределяются по фиксированному набору очередей по chat.ID, поэтому сообщения
+// одного чата обрабатываются последовательно, а разные чаты – одновременно.
+// Обновления без чата распределяются по UpdateID.
+//
+// Dispatch только ставит обновление в очередь; ошибки и паники обработчика логируются.
+// Для использования с поллером передайте d.Dispatch в опцию WithUpdateHandler.
+type OrderedDispatcher struct {
+	mu      sync.RWMutex
+	closed  bool
+	queues  []chan Update
+	wg      sync.WaitGroup
+	handler HandlerFunc
+	logger  Logger
+}
+
+// NewOrderedDispatcher создаёт диспетчер с workers очередями (минимум одна) и сразу запускает их обработку.
+func NewOrderedDispatcher(handler HandlerFunc, workers int, logger Logger) *OrderedDispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	d := &OrderedDispatcher{
+		queues:  make([]chan Update, workers),
+		handler: handler,
+		logger:  logger,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan Update, orderedQueueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+	return d
+}
+
+// Dispatch ставит обновление в очередь его чата. Если очередь заполнена, вызов блокируется.
+// После Close возвращается ErrDispatcherClosed.
+func (d *OrderedDispatcher) Dispatch(update Update) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+	d.queues[d.queueIndex(update)] <- update
+	return nil
+}
+
+// Close прекращает приём обновлений и дожидается обработки уже поставленных в очередь.
+func (d *OrderedDispatcher) Close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, queue := range d.queues {
+			close(queue)
+		}
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// queueIndex выбирает очередь для обновления.
+func (d *OrderedDispatcher) queueIndex(update Update) int {
+	key := uint64(update.UpdateID)
+	if chat := update.EffectiveChat(); chat != nil {
+		key = uint64(chat.ID)
+	}
+	return int(key % uint64(len(d.queues)))
+}
+
+// work последовательно обрабатывает обновления одной очереди.
+func (d *OrderedDispatcher) work(queue <-chan Update) {
+	defer d.wg.Done()
+	for update := range queue {
+		logger := LoggerWithCorrelation(d.logger, update.Context())
+		var err error
+		WithRecovery(logger, func() {
+			err = d.handler(update)
+		})
+		if err != nil {
+			logger.Error("Error handling update", Field{"update_id", update.UpdateID}, Field{"error", err})
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestOrderedDispatcherPreservesPerChatOrder(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[int64][]int)
+	d := NewOrderedDispatcher(func(update Update) error {
+		mu.Lock()
+		defer mu.Unlock()
+		chatID := update.Message.Chat.ID
+		seen[chatID] = append(seen[chatID], update.UpdateID)
+		return nil
+	}, 4, newTestLogger())
+
+	for i := 0; i < 300; i++ {
+		update := Update{UpdateID: i, Message: &Message{Chat: Chat{ID: int64(i%7) - 3}}}
+		if err := d.Dispatch(update); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+	}
+	d.Close()
+
+	total := 0
+	for chatID, ids := range seen {
+		total += len(ids)
+		for i := 1; i < len(ids); i++ {
+			if ids[i] < ids[i-1] {
+				t.Fatalf("chat %d: update %d handled after %d", chatID, ids[i], ids[i-1])
+			}
+		}
+	}
+	if total != 300 {
+		t.Errorf("handled %d updates, want 300", total)
+	}
+	if err := d.Dispatch(Update{}); err != ErrDispatcherClosed {
+		t.Errorf("Dispatch after Close returned %v, want ErrDispatcherClosed", err)
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+// Источники ошибок Telegram Passport для поля PassportElementError.Source.
+const (
+	PassportErrorSourceData             = "data"
+	PassportErrorSourceFrontSide        = "front_side"
+	PassportErrorSourceReverseSide      = "reverse_side"
+	PassportErrorSourceSelfie           = "selfie"
+	PassportErrorSourceFile             = "file"
+	PassportErrorSourceFiles            = "files"
+	PassportErrorSourceTranslationFile  = "translation_file"
+	PassportErrorSourceTranslationFiles = "translation_files"
+	PassportErrorSourceUnspecified      = "unspecified"
+)
+
+// PassportElementError описывает ошибку в данных Telegram Passport, отправленных пользователем.
+// Type – тип элемента Passport ("passport", "driver_license", "utility_bill" и т.д.).
+// Какое поле хеша заполняется, зависит от Source, поэтому лучше использовать конструкторы
+// ниже – они задают нужное сочетание полей для каждого варианта.
+type PassportElementError struct {
+	Source      string   `json:"source"`
+	Type        string   `json:"type"`
+	FieldName   string   `json:"field_name,omitempty"`
+	DataHash    string   `json:"data_hash,omitempty"`
+	FileHash    string   `json:"file_hash,omitempty"`
+	FileHashes  []string `json:"file_hashes,omitempty"`
+	ElementHash string   `json:"element_hash,omitempty"`
+	Message     string   `json:"message"`
+}
+
+// NewPassportDataFieldError сообщает об ошибке в поле данных элемента.
+func NewPassportDataFieldError(elementType, fieldName, dataHash, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceData, Type: elementType, FieldName: fieldName, DataHash: dataHash, Message: message}
+}
+
+// NewPassportFrontSideError сообщает о проблеме с лицевой стороной документа.
+func NewPassportFrontSideError(elementType, fileHash, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceFrontSide, Type: elementType, FileHash: fileHash, Message: message}
+}
+
+// NewPassportReverseSideError сообщает о проблеме с оборотной стороной документа.
+func NewPassportReverseSideError(elementType, fileHash, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceReverseSide, Type: elementType, FileHash: fileHash, Message: message}
+}
+
+// NewPassportSelfieError сообщает о проблеме с селфи с документом.
+func NewPassportSelfieError(elementType, fileHash, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceSelfie, Type: elementType, FileHash: fileHash, Message: message}
+}
+
+// NewPassportFileError сообщает о проблеме со сканом документа.
+func NewPassportFileError(elementType, fileHash, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceFile, Type: elementType, FileHash: fileHash, Message: message}
+}
+
+// NewPassportFilesError сообщает о проблеме со списком сканов документа.
+func NewPassportFilesError(elementType string, fileHashes []string, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceFiles, Type: elementType, FileHashes: fileHashes, Message: message}
+}
+
+// NewPassportTranslationFileError сообщает о проблеме с одним из файлов перевода документа.
+func NewPassportTranslationFileError(elementType, fileHash, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceTranslationFile, Type: elementType, FileHash: fileHash, Message: message}
+}
+
+// NewPassportTranslationFilesError сообщает о проблеме с переводом документа.
+func NewPassportTranslationFilesError(elementType string, fileHashes []string, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceTranslationFiles, Type: elementType, FileHashes: fileHashes, Message: message}
+}
+
+// NewPassportUnspecifiedError сообщает о проблеме в неуказанном месте элемента.
+func NewPassportUnspecifiedError(elementType, elementHash, message string) PassportElementError {
+	return PassportElementError{Source: PassportErrorSourceUnspecified, Type: elementType, ElementHash: elementHash, Message: message}
+}
@@ -2,70 +2,299 @@ package core
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 )
 
+var (
+	// ErrPollerRunning возвращается Start, если поллер уже запущен.
+	ErrPollerRunning = errors.New("poller is already running")
+	// ErrPollerNotRunning возвращается Stop, если поллер не запущен или уже остановлен.
+	ErrPollerNotRunning = errors.New("poller is not running")
+)
+
 // Poller – интерфейс для получения обновлений с поддержкой контекста.
 type Poller interface {
+	// Start запускает цикл поллинга в отдельной горутине и сразу возвращает управление.
+	// Если ctx уже отменён, цикл не запускается и возвращается ctx.Err().
 	Start(ctx context.Context) error
+	// Stop отменяет цикл и сразу возвращает управление, не дожидаясь его завершения,
+	// поэтому его можно вызывать и из обработчика обновления (например, команды "/shutdown").
+	// Повторный вызов безопасен и возвращает ErrPollerNotRunning, как и вызов
+	// для не запущенного поллера. Пока отменённый цикл не завершился, Start возвращает
+	// ErrPollerRunning; для перезапуска вызовите Wait, а затем Start.
 	Stop() error
+	// Wait дожидается завершения цикла после Stop или отмены контекста Start.
+	// Если цикл не запускался, возвращает управление сразу. Wait нельзя вызывать
+	// из обработчика обновления: цикл ждёт возврата обработчика, и вызов заблокируется навсегда.
+	Wait()
+	// Updates запускает цикл поллинга, как Start, но вместо роутера передаёт обновления
+	// в возвращаемый канал (см. Serve). Канал закрывается после отмены ctx или вызова Stop.
+	UpdateSource
+}
+
+// PollerOption задаёт необязательные параметры поллера.
+type PollerOption func(*pollingImpl)
+
+// OnFetchError регистрирует функцию, вызываемую при ошибке получения обновлений.
+func OnFetchError(fn func(err error)) PollerOption {
+	return func(p *pollingImpl) {
+		p.onFetchError = fn
+	}
+}
+
+// OnHandlerError регистрирует функцию, вызываемую при ошибке обработки обновления роутером.
+func OnHandlerError(fn func(update Update, err error)) PollerOption {
+	return func(p *pollingImpl) {
+		p.onHandlerError = fn
+	}
+}
+
+// WithUpdateHandler задаёт функцию, которой поллер передаёт обновления вместо router.Route,
+// например Dispatch у OrderedDispatcher или Dispatcher.
+func WithUpdateHandler(handler HandlerFunc) PollerOption {
+	return func(p *pollingImpl) {
+		p.handle = handler
+	}
+}
+
+// WithAuditSink сохраняет исходный JSON каждого полученного обновления в sink до его обработки.
+func WithAuditSink(sink AuditSink) PollerOption {
+	return func(p *pollingImpl) {
+		p.audit = sink
+	}
+}
+
+// PollerMetrics получает показатели работы поллера. Ему удовлетворяет *metrics.PrometheusMetrics.
+type PollerMetrics interface {
+	// IncUpdatesReceived увеличивает счётчик полученных обновлений на n.
+	IncUpdatesReceived(n int)
+	// IncPollErrors учитывает неудачный запрос getUpdates.
+	IncPollErrors()
+	// SetLastPollTimestamp фиксирует время последнего успешного запроса.
+	SetLastPollTimestamp(t time.Time)
+	// SetCurrentOffset фиксирует текущее смещение поллера.
+	SetCurrentOffset(offset int)
+}
+
+// WithPollerMetrics включает сбор метрик поллера: число полученных обновлений, ошибок,
+// время последнего успешного запроса и текущее смещение. По значению времени последнего
+// запроса удобно обнаруживать зависший цикл.
+func WithPollerMetrics(m PollerMetrics) PollerOption {
+	return func(p *pollingImpl) {
+		p.metrics = m
+	}
 }
 
 // pollingImpl – реализация поллинга, использующая контекст для корректного завершения.
 type pollingImpl struct {
-	api      BotAPI
-	router   Router
-	offset   int
-	logger   Logger
+	api            BotAPI
+	router         Router
+	offset         int
+	logger         Logger
+	onFetchError   func(err error)
+	onHandlerError func(update Update, err error)
+	handle         HandlerFunc
+	metrics        PollerMetrics
+	audit          AuditSink
+
+	// mu защищает состояние запуска: cancel != nil, пока цикл работает (в том числе
+	// после Stop, до его завершения); stopping отмечает, что Stop для цикла уже вызван.
+	mu       sync.Mutex
 	cancel   context.CancelFunc
+	stopping bool
+	done     chan struct{}
 }
 
 // NewPoller создаёт новый экземпляр Poller с заданными API, роутером и логгером.
-func NewPoller(api BotAPI, router Router, logger Logger) Poller {
-	return &pollingImpl{
+// Дополнительные параметры (например, колбэки ошибок) передаются через opts.
+func NewPoller(api BotAPI, router Router, logger Logger, opts ...PollerOption) Poller {
+	p := &pollingImpl{
 		api:    api,
 		router: router,
 		logger: logger,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.handle == nil {
+		p.handle = router.Route
+	}
+	return p
 }
 
 // Start запускает процесс поллинга с использованием переданного контекста.
 // При отмене контекста цикл завершится корректно.
 func (p *pollingImpl) Start(ctx context.Context) error {
+	return p.launch(ctx, p.loop)
+}
+
+// Updates запускает процесс поллинга и передаёт полученные обновления в канал.
+// Смещение сдвигается только после того, как обновление принято из канала.
+func (p *pollingImpl) Updates(ctx context.Context) (<-chan Update, error) {
+	updates := make(chan Update)
+	err := p.launch(ctx, func(ctx context.Context) {
+		defer close(updates)
+		p.poll(ctx, func(update Update) bool {
+			select {
+			case updates <- update:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// launch запускает run в отдельной горутине, если поллер не запущен и прежний цикл завершился.
+func (p *pollingImpl) launch(ctx context.Context, run func(ctx context.Context)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		return ErrPollerRunning
+	}
 	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
 	p.cancel = cancel
+	p.stopping = false
+	p.done = done
 
 	go func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				p.logger.Info("Polling stopped due to context cancellation")
-				return
-			case <-ticker.C:
-				updates, err := p.api.GetUpdates(ctx, p.offset, 100, 60)
-				if err != nil {
-					p.logger.Error("Error fetching updates", Field{"error", err})
-					continue
+		defer func() {
+			cancel()
+			p.mu.Lock()
+			p.cancel = nil
+			p.stopping = false
+			close(done)
+			p.mu.Unlock()
+		}()
+		run(ctx)
+	}()
+
+	return nil
+}
+
+// loop получает и обрабатывает обновления до отмены ctx.
+func (p *pollingImpl) loop(ctx context.Context) {
+	p.poll(ctx, func(update Update) bool {
+		if err := p.handle(update); err != nil {
+			LoggerWithCorrelation(p.logger, update.Context()).Error("Error routing update", Field{"error", err})
+			p.reportHandlerError(update, err)
+		}
+		return true
+	})
+}
+
+// poll запрашивает обновления до отмены ctx и передаёт каждое в emit.
+// Если emit возвращает false, обновление считается непринятым и poll завершается.
+func (p *pollingImpl) poll(ctx context.Context, emit func(update Update) bool) {
+	// Обработчики получают клиент поллера через APIFromContext.
+	updateCtx := ContextWithAPI(ctx, p.api)
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Polling stopped due to context cancellation")
+			return
+		case <-ticker.C:
+			updates, err := p.api.GetUpdates(ctx, p.offset, 100, 60)
+			if err != nil {
+				p.logger.Error("Error fetching updates", Field{"error", err})
+				if p.metrics != nil {
+					p.metrics.IncPollErrors()
+				}
+				p.reportFetchError(err)
+				continue
+			}
+			if p.metrics != nil {
+				p.metrics.SetLastPollTimestamp(time.Now())
+				p.metrics.IncUpdatesReceived(len(updates))
+			}
+			for _, update := range updates {
+				update = withNewCorrelationID(updateCtx, update)
+				if p.audit != nil {
+					auditUpdate(p.audit, update, LoggerWithCorrelation(p.logger, update.Context()))
 				}
-				for _, update := range updates {
-					if err := p.router.Route(update); err != nil {
-						p.logger.Error("Error routing update", Field{"error", err})
-					}
-					p.offset = update.UpdateID + 1
+				if !emit(update) {
+					return
 				}
+				p.offset = update.UpdateID + 1
+			}
+			if p.metrics != nil {
+				p.metrics.SetCurrentOffset(p.offset)
 			}
 		}
-	}()
+	}
+}
 
-	return nil
+// reportFetchError передаёт ошибку получения обновлений в пользовательский колбэк, если он задан.
+// Паника в колбэке перехватывается, чтобы не остановить цикл поллинга.
+func (p *pollingImpl) reportFetchError(err error) {
+	if p.onFetchError == nil {
+		return
+	}
+	WithRecovery(p.logger, func() {
+		p.onFetchError(err)
+	})
+}
+
+// reportHandlerError передаёт ошибку обработки обновления в пользовательский колбэк, если он задан.
+func (p *pollingImpl) reportHandlerError(update Update, err error) {
+	if p.onHandlerError == nil {
+		return
+	}
+	WithRecovery(p.logger, func() {
+		p.onHandlerError(update, err)
+	})
+}
+
+// FetchUpdates однократно запрашивает обновления начиная с offset без запуска поллера
+// и возвращает их вместе со смещением для следующего вызова. Удобно для скриптов и утилит.
+// Запрос выполняется без long polling (timeout = 0), поэтому при отсутствии обновлений
+// возвращается пустой срез и прежний offset.
+func FetchUpdates(ctx context.Context, api BotAPI, offset int) ([]Update, int, error) {
+	updates, err := api.GetUpdates(ctx, offset, 100, 0)
+	if err != nil {
+		return nil, offset, err
+	}
+	next := offset
+	for _, update := range updates {
+		if update.UpdateID >= next {
+			next = update.UpdateID + 1
+		}
+	}
+	return updates, next, nil
 }
 
-// Stop отменяет выполнение поллинга.
+// Stop отменяет выполнение поллинга, не дожидаясь завершения цикла (см. Wait).
+// Если цикл не запущен, уже остановлен или завершился из-за отмены контекста Start,
+// возвращается ErrPollerNotRunning.
 func (p *pollingImpl) Stop() error {
-	if p.cancel != nil {
-		p.cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel == nil || p.stopping {
+		return ErrPollerNotRunning
 	}
+	p.stopping = true
+	p.cancel()
 	return nil
 }
+
+// Wait дожидается завершения последнего запущенного цикла.
+func (p *pollingImpl) Wait() {
+	p.mu.Lock()
+	done := p.done
+	p.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
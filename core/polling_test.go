@@ -0,0 +1,235 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUpdatesAPI – BotAPI, в котором реализован только GetUpdates.
+type fakeUpdatesAPI struct {
+	BotAPI
+	updates []Update
+	err     error
+	offset  int
+}
+
+func (f *fakeUpdatesAPI) GetUpdates(ctx context.Context, offset, limit, timeout int) ([]Update, error) {
+	f.offset = offset
+	return f.updates, f.err
+}
+
+func TestFetchUpdates(t *testing.T) {
+	api := &fakeUpdatesAPI{updates: []Update{{UpdateID: 10}, {UpdateID: 11}}}
+	updates, next, err := FetchUpdates(context.Background(), api, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(updates) != 2 || next != 12 {
+		t.Errorf("got %d updates, next offset %d; want 2 and 12", len(updates), next)
+	}
+	if api.offset != 10 {
+		t.Errorf("GetUpdates called with offset %d, want 10", api.offset)
+	}
+}
+
+func TestFetchUpdatesEmptyKeepsOffset(t *testing.T) {
+	api := &fakeUpdatesAPI{}
+	_, next, err := FetchUpdates(context.Background(), api, 5)
+	if err != nil || next != 5 {
+		t.Errorf("got next=%d err=%v, want 5 and nil", next, err)
+	}
+}
+
+func TestFetchUpdatesError(t *testing.T) {
+	api := &fakeUpdatesAPI{err: errors.New("boom")}
+	if _, next, err := FetchUpdates(context.Background(), api, 7); err == nil || next != 7 {
+		t.Errorf("got next=%d err=%v, want 7 and error", next, err)
+	}
+}
+
+func TestPollerStartStopLifecycle(t *testing.T) {
+	p := NewPoller(&fakeUpdatesAPI{}, NewRouter(newTestLogger()), newTestLogger())
+
+	if err := p.Stop(); err != ErrPollerNotRunning {
+		t.Errorf("Stop before Start returned %v, want ErrPollerNotRunning", err)
+	}
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := p.Start(context.Background()); err != ErrPollerRunning {
+		t.Errorf("second Start returned %v, want ErrPollerRunning", err)
+	}
+	if err := p.Stop(); err != nil {
+		t.Errorf("Stop returned %v", err)
+	}
+	p.Wait()
+	if err := p.Stop(); err != ErrPollerNotRunning {
+		t.Errorf("second Stop returned %v, want ErrPollerNotRunning", err)
+	}
+}
+
+func TestPollerStopFromHandler(t *testing.T) {
+	stopped := make(chan error, 1)
+	var p Poller
+	p = NewPoller(&fakeUpdatesAPI{updates: []Update{{UpdateID: 1}}}, NewRouter(newTestLogger()), newTestLogger(),
+		WithUpdateHandler(func(update Update) error {
+			// Например, обработчик команды "/shutdown".
+			stopped <- p.Stop()
+			return nil
+		}),
+	)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("Stop from handler returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop called from a handler blocked")
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		p.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("loop did not finish after Stop")
+	}
+}
+
+func TestPollerRestartWaitsForPreviousLoop(t *testing.T) {
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	p := NewPoller(&fakeUpdatesAPI{updates: []Update{{UpdateID: 1}}}, NewRouter(newTestLogger()), newTestLogger(),
+		WithUpdateHandler(func(update Update) error {
+			select {
+			case entered <- struct{}{}:
+			default:
+			}
+			<-release
+			return nil
+		}),
+	)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	<-entered
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if err := p.Start(context.Background()); err != ErrPollerRunning {
+		t.Errorf("Start while the stopped loop is in a handler returned %v, want ErrPollerRunning", err)
+	}
+	if err := p.Stop(); err != ErrPollerNotRunning {
+		t.Errorf("second Stop returned %v, want ErrPollerNotRunning", err)
+	}
+	close(release)
+	p.Wait()
+
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start after Wait: %v", err)
+	}
+	<-entered
+	if err := p.Stop(); err != nil {
+		t.Errorf("Stop after restart: %v", err)
+	}
+	p.Wait()
+}
+
+func TestPollerStartWithCanceledContext(t *testing.T) {
+	p := NewPoller(&fakeUpdatesAPI{}, NewRouter(newTestLogger()), newTestLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Start(ctx); err != context.Canceled {
+		t.Fatalf("Start returned %v, want context.Canceled", err)
+	}
+	if err := p.Stop(); err != ErrPollerNotRunning {
+		t.Errorf("Stop returned %v, want ErrPollerNotRunning", err)
+	}
+}
+
+// scriptedUpdatesAPI – BotAPI, в котором GetUpdates сначала возвращает ошибку, затем одно
+// обновление, а дальше пустые ответы. Номер каждого вызова отправляется в calls.
+type scriptedUpdatesAPI struct {
+	BotAPI
+	mu    sync.Mutex
+	n     int
+	calls chan int
+}
+
+func (f *scriptedUpdatesAPI) GetUpdates(ctx context.Context, offset, limit, timeout int) ([]Update, error) {
+	f.mu.Lock()
+	f.n++
+	n := f.n
+	f.mu.Unlock()
+	select {
+	case f.calls <- n:
+	default:
+	}
+	switch n {
+	case 1:
+		return nil, errors.New("network down")
+	case 2:
+		return []Update{{UpdateID: 1}}, nil
+	}
+	return nil, nil
+}
+
+func TestPollerErrorCallbacksSurvivePanics(t *testing.T) {
+	api := &scriptedUpdatesAPI{calls: make(chan int, 10)}
+	fetchErrs := make(chan error, 1)
+	handlerErrs := make(chan error, 1)
+	handlerErr := errors.New("handler failed")
+	p := NewPoller(api, NewRouter(newTestLogger()), newTestLogger(),
+		WithUpdateHandler(func(update Update) error { return handlerErr }),
+		OnFetchError(func(err error) {
+			fetchErrs <- err
+			panic("fetch callback")
+		}),
+		OnHandlerError(func(update Update, err error) {
+			handlerErrs <- err
+			panic("handler callback")
+		}),
+	)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Wait()
+	defer p.Stop()
+
+	select {
+	case err := <-fetchErrs:
+		if err == nil || err.Error() != "network down" {
+			t.Errorf("OnFetchError got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnFetchError was not called")
+	}
+	select {
+	case err := <-handlerErrs:
+		if err != handlerErr {
+			t.Errorf("OnHandlerError got %v, want %v", err, handlerErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnHandlerError was not called after a panicking OnFetchError")
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case n := <-api.calls:
+			if n >= 3 {
+				return
+			}
+		case <-deadline:
+			t.Fatal("poller stopped fetching after a panicking OnHandlerError")
+		}
+	}
+}
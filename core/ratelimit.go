@@ -0,0 +1,138 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRequestsPerSecond – общий лимит Telegram на число запросов бота в секунду.
+	DefaultRequestsPerSecond = 30
+	// PaidBroadcastRequestsPerSecond – лимит для рассылок с allow_paid_broadcast (WithAllowPaidBroadcast),
+	// оплачиваемых Telegram Stars сверх бесплатных DefaultRequestsPerSecond.
+	PaidBroadcastRequestsPerSecond = 1000
+)
+
+// RateLimiter распределяет запросы к Bot API одного токена между несколькими клиентами
+// (botClient, payments и др.): ограничивает их общую частоту и при ответе 429 приостанавливает
+// все запросы на время retry_after, указанное Telegram. Один экземпляр передаётся во все
+// конструкторы, использующие токен: NewBotClient(..., WithRateLimiter(l)),
+// payments.NewPaymentService(..., payments.WithRateLimiter(l)).
+type RateLimiter struct {
+	mu          sync.Mutex
+	interval    time.Duration
+	next        time.Time
+	pausedUntil time.Time
+	now         func() time.Time
+}
+
+// NewRateLimiter создаёт лимитер, пропускающий не более requestsPerSecond запросов в секунду.
+// При requestsPerSecond <= 0 частота не ограничивается, но паузы по retry_after соблюдаются.
+func NewRateLimiter(requestsPerSecond int) *RateLimiter {
+	l := &RateLimiter{now: time.Now}
+	if requestsPerSecond > 0 {
+		l.interval = time.Second / time.Duration(requestsPerSecond)
+	}
+	return l
+}
+
+// Wait блокируется до момента, когда можно выполнить очередной запрос, или до отмены ctx.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	at := l.now()
+	if l.next.After(at) {
+		at = l.next
+	}
+	if l.pausedUntil.After(at) {
+		at = l.pausedUntil
+	}
+	l.next = at.Add(l.interval)
+	delay := at.Sub(l.now())
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PauseFor приостанавливает все запросы через лимитер на время d.
+func (l *RateLimiter) PauseFor(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// WrapClient возвращает копию httpClient, запросы которой проходят через лимитер.
+// Исходный клиент не изменяется; nil заменяется клиентом по умолчанию.
+func (l *RateLimiter) WrapClient(httpClient *http.Client) *http.Client {
+	var client http.Client
+	if httpClient != nil {
+		client = *httpClient
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &rateLimitedTransport{next: next, limiter: l}
+	return &client
+}
+
+// WithRateLimiter направляет запросы клиента через общий лимитер токена.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(b *botClient) {
+		b.httpClient = limiter.WrapClient(b.httpClient)
+	}
+}
+
+// rateLimitedTransport – http.RoundTripper, ожидающий лимитер перед запросом
+// и сообщающий ему о паузах из ответов 429.
+type rateLimitedTransport struct {
+	next    http.RoundTripper
+	limiter *RateLimiter
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	body, err := ReadLimited(resp.Body, DefaultMaxResponseSize)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.limiter.PauseFor(retryAfter(body))
+	return resp, nil
+}
+
+// retryAfter извлекает parameters.retry_after из ответа Bot API; по умолчанию – одна секунда.
+func retryAfter(body []byte) time.Duration {
+	var result struct {
+		Parameters struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if json.Unmarshal(body, &result) == nil && result.Parameters.RetryAfter > 0 {
+		return time.Duration(result.Parameters.RetryAfter) * time.Second
+	}
+	return time.Second
+}
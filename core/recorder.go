@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+)
+
+// RecordedCall – запись об одном запросе к Bot API.
+type RecordedCall struct {
+	// Method – имя метода Bot API, например "sendMessage".
+	Method string
+	// Params – параметры запроса. Числа из JSON сохраняются как json.Number,
+	// параметры строки запроса – как string.
+	Params map[string]interface{}
+}
+
+// CallRecorder накапливает запросы клиента к Bot API для проверок в тестах.
+// Подключается к клиенту опцией WithCallRecorder.
+type CallRecorder struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+}
+
+// NewCallRecorder создаёт пустой CallRecorder.
+func NewCallRecorder() *CallRecorder {
+	return &CallRecorder{}
+}
+
+// Calls возвращает копию всех записанных вызовов в порядке их выполнения.
+func (r *CallRecorder) Calls() []RecordedCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([]RecordedCall, len(r.calls))
+	copy(calls, r.calls)
+	return calls
+}
+
+// CallsTo возвращает записанные вызовы указанного метода Bot API.
+func (r *CallRecorder) CallsTo(method string) []RecordedCall {
+	var calls []RecordedCall
+	for _, call := range r.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset очищает список записанных вызовов.
+func (r *CallRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *CallRecorder) record(call RecordedCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+// WithCallRecorder записывает каждый запрос клиента к Bot API в recorder.
+// Переданный в NewBotClient HTTP-клиент не изменяется: клиент использует его копию.
+func WithCallRecorder(recorder *CallRecorder) ClientOption {
+	return func(b *botClient) {
+		client := *b.httpClient
+		next := client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.Transport = &recordingTransport{next: next, recorder: recorder}
+		b.httpClient = &client
+	}
+}
+
+// recordingTransport – http.RoundTripper, сохраняющий метод и параметры запроса.
+type recordingTransport struct {
+	next     http.RoundTripper
+	recorder *CallRecorder
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := RecordedCall{
+		Method: path.Base(req.URL.Path),
+		Params: make(map[string]interface{}),
+	}
+	for key := range req.URL.Query() {
+		call.Params[key] = req.URL.Query().Get(key)
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var params map[string]interface{}
+		if decoder.Decode(&params) == nil {
+			for key, value := range params {
+				call.Params[key] = value
+			}
+		}
+	}
+	t.recorder.record(call)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer ts.Close()
+
+	recorder := NewCallRecorder()
+	api := NewBotClient("TEST_TOKEN", newTestLogger(), ts.Client(), WithCallRecorder(recorder)).(*botClient)
+	api.apiURL = ts.URL + "/botTEST_TOKEN"
+
+	if err := api.SendMessage(context.Background(), ChatIDFromInt(42), "hello"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if _, err := api.GetChat(context.Background(), ChatIDFromInt(42)); err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+
+	calls := recorder.CallsTo("sendMessage")
+	if len(calls) != 1 {
+		t.Fatalf("got %d sendMessage calls, want 1", len(calls))
+	}
+	if calls[0].Params["chat_id"] != json.Number("42") || calls[0].Params["text"] != "hello" {
+		t.Errorf("unexpected params: %v", calls[0].Params)
+	}
+	if all := recorder.Calls(); len(all) != 2 || all[1].Method != "getChat" || all[1].Params["chat_id"] != "42" {
+		t.Errorf("unexpected calls: %+v", all)
+	}
+
+	recorder.Reset()
+	if len(recorder.Calls()) != 0 {
+		t.Error("Reset did not clear calls")
+	}
+}
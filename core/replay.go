@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReplayOption задаёт необязательные параметры Replay.
+type ReplayOption func(*replayConfig)
+
+type replayConfig struct {
+	limiter *RateLimiter
+}
+
+// WithReplayRate ограничивает скорость воспроизведения: не более perSecond обновлений в секунду.
+// Полезно, если обработчики обращаются к Bot API или внешним сервисам.
+func WithReplayRate(perSecond int) ReplayOption {
+	return func(c *replayConfig) {
+		if perSecond > 0 {
+			c.limiter = NewRateLimiter(perSecond)
+		}
+	}
+}
+
+// Replay читает обновления в формате JSON Lines из source (например, файл FileAuditSink)
+// и по очереди передаёт их в router.Route. Каждое обновление получает собственный correlation ID.
+// Ошибки обработчиков не прерывают воспроизведение и возвращаются вместе через errors.Join;
+// чтение прекращается при отмене ctx или ошибке разбора source.
+func Replay(ctx context.Context, source io.Reader, router Router, opts ...ReplayOption) error {
+	var cfg replayConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var handlerErrs []error
+	decoder := json.NewDecoder(source)
+	for {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(handlerErrs, err)...)
+		}
+		var update Update
+		if err := decoder.Decode(&update); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Join(append(handlerErrs, fmt.Errorf("decode update: %w", err))...)
+		}
+		if cfg.limiter != nil {
+			if err := cfg.limiter.Wait(ctx); err != nil {
+				return errors.Join(append(handlerErrs, err)...)
+			}
+		}
+		if err := router.Route(withNewCorrelationID(ctx, update)); err != nil {
+			handlerErrs = append(handlerErrs, fmt.Errorf("update %d: %w", update.UpdateID, err))
+		}
+	}
+	return errors.Join(handlerErrs...)
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReplayRoutesRecordedUpdates(t *testing.T) {
+	source := strings.NewReader(
+		`{"update_id":1,"message":{"message_id":1,"chat":{"id":1},"text":"/start"}}` + "\n" +
+			`{"update_id":2,"message":{"message_id":2,"chat":{"id":1},"text":"/fail"}}` + "\n" +
+			`{"update_id":3,"message":{"message_id":3,"chat":{"id":1},"text":"/start"}}` + "\n")
+
+	router := NewRouter(newTestLogger())
+	var routed []int
+	router.HandleCommand("/start", func(update Update) error {
+		if CorrelationIDFromContext(update.Context()) == "" {
+			t.Error("replayed update has no correlation ID")
+		}
+		routed = append(routed, update.UpdateID)
+		return nil
+	})
+	errBoom := errors.New("boom")
+	router.HandleCommand("/fail", func(update Update) error { return errBoom })
+
+	err := Replay(context.Background(), source, router, WithReplayRate(1000))
+	if !errors.Is(err, errBoom) {
+		t.Errorf("got %v, want error wrapping the handler error", err)
+	}
+	if len(routed) != 2 || routed[0] != 1 || routed[1] != 3 {
+		t.Errorf("routed %v, want [1 3]", routed)
+	}
+}
+
+func TestReplayStopsOnMalformedInput(t *testing.T) {
+	err := Replay(context.Background(), strings.NewReader(`{"update_id":1}`+"\n{oops"), NewRouter(newTestLogger()))
+	if err == nil || !strings.Contains(err.Error(), "decode update") {
+		t.Errorf("got %v, want decode error", err)
+	}
+}
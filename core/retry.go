@@ -0,0 +1,130 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// maxRetryDelay ограничивает паузу между повторами при экспоненциальном росте.
+const maxRetryDelay = 30 * time.Second
+
+// WithRetry включает повтор запросов к Bot API при временных сбоях: до maxRetries повторов
+// после первой попытки. Ответ 429 повторяется для любого метода через retry_after секунд,
+// указанных Telegram; ответы 5xx – только для читающих методов (get*) с паузой baseDelay,
+// удваиваемой с каждой попыткой. Повтор не выполняется, если пауза не укладывается в дедлайн
+// контекста запроса: тогда возвращается последний ответ. Отмена контекста во время паузы
+// сразу прерывает запрос с ошибкой ctx.Err(). Каждый повтор логируется на уровне Warn.
+func WithRetry(maxRetries int, baseDelay time.Duration) ClientOption {
+	return func(b *botClient) {
+		b.maxRetries = maxRetries
+		b.retryBaseDelay = baseDelay
+	}
+}
+
+// NewBotClientWithOptions создаёт клиент, как NewBotClient, с повтором временных сбоев
+// (см. WithRetry) не более maxRetries раз и начальной паузой baseDelay.
+func NewBotClientWithOptions(token string, logger Logger, httpClient *http.Client, maxRetries int, baseDelay time.Duration, opts ...ClientOption) BotAPI {
+	return NewBotClient(token, logger, httpClient, append(opts, WithRetry(maxRetries, baseDelay))...)
+}
+
+// wrapRetry оборачивает транспорт клиента повторами. Вызывается после применения всех
+// опций, поэтому повторы охватывают лимитер и запись вызовов.
+func (b *botClient) wrapRetry() {
+	if b.maxRetries <= 0 {
+		return
+	}
+	client := *b.httpClient
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &retryTransport{
+		next:       next,
+		maxRetries: b.maxRetries,
+		baseDelay:  b.retryBaseDelay,
+		logger:     b.logger,
+	}
+	b.httpClient = &client
+}
+
+// retryTransport – http.RoundTripper, повторяющий запросы при ответах 429 и 5xx.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+	logger     Logger
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || attempt > t.maxRetries || !retryableStatus(method, resp.StatusCode) {
+			return resp, err
+		}
+		// Тело запроса нельзя отправить повторно.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+		body, err := ReadLimited(resp.Body, DefaultMaxResponseSize)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		delay := t.backoff(attempt)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			delay = retryAfter(body)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, nil
+		}
+		LoggerWithCorrelation(t.logger, ctx).Warn("Retrying "+method+" after transient error",
+			Field{"attempt", attempt},
+			Field{"status", resp.Status},
+			Field{"delay", delay.String()},
+		)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+
+		req = req.Clone(ctx)
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// backoff возвращает паузу перед повтором номер attempt.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// retryableStatus сообщает, стоит ли повторять запрос method после ответа с кодом status.
+// Ответ 429 означает, что запрос не выполнен, поэтому повторяется всегда; после 5xx
+// повторяются только читающие методы, чтобы не отправить сообщение дважды.
+func retryableStatus(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	return status >= 500 && strings.HasPrefix(method, "get")
+}
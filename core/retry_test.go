@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRetryTestClient(t *testing.T, handler http.HandlerFunc) *botClient {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	api := NewBotClientWithOptions("TEST_TOKEN", newTestLogger(), ts.Client(), 3, time.Millisecond).(*botClient)
+	api.apiURL = ts.URL + "/botTEST_TOKEN"
+	return api
+}
+
+func TestRetryReadMethodsOn5xx(t *testing.T) {
+	var calls int32
+	api := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot"}}`))
+	})
+	if _, err := api.GetMe(context.Background()); err != nil {
+		t.Fatalf("GetMe: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("got %d attempts, want 3", calls)
+	}
+}
+
+func TestNoRetryForSendOn5xx(t *testing.T) {
+	var calls int32
+	api := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	if err := api.SendMessage(context.Background(), ChatIDFromInt(1), "hi"); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("sendMessage attempted %d times, want 1", calls)
+	}
+}
+
+func TestRetrySkippedWhenDeadlineIsShorter(t *testing.T) {
+	var calls int32
+	api := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"ok":false,"error_code":429,"parameters":{"retry_after":5}}`))
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := api.GetUpdates(ctx, 0, 100, 0); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("got %d attempts, want 1", calls)
+	}
+}
+
+func TestRetrySleepStopsOnCancel(t *testing.T) {
+	api := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"ok":false,"error_code":429,"parameters":{"retry_after":5}}`))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := api.SendMessage(ctx, ChatIDFromInt(1), "hi")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("call returned after %s, want prompt return on cancel", elapsed)
+	}
+}
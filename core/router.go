@@ -1,158 +1,394 @@
 package core
 
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
 // HandlerFunc – функция-обработчик для обновления.
 // Возвращает ошибку, если обработка обновления завершилась неудачно.
 type HandlerFunc func(update Update) error
 
+// Middleware оборачивает HandlerFunc дополнительной логикой.
+type Middleware func(HandlerFunc) HandlerFunc
+
 // Router – интерфейс для маршрутизации обновлений.
 type Router interface {
 	// HandleCommand регистрирует обработчик для команд (например, "/start").
 	HandleCommand(command string, handler HandlerFunc)
+	// HandleCommandWithDescription регистрирует обработчик команды вместе с её описанием
+	// для справки (/help) и меню команд Telegram.
+	HandleCommandWithDescription(command, description string, handler HandlerFunc)
+	// Commands возвращает описанные команды, отсортированные по имени.
+	Commands() []BotCommand
+	// RegisterWithTelegram передаёт описанные команды в меню бота через setMyCommands.
+	RegisterWithTelegram(ctx context.Context, api BotAPI) error
 	// HandleCallback регистрирует обработчик для колбэков.
 	HandleCallback(callbackData string, handler HandlerFunc)
-	HandleDocument(handler HandlerFunc) // универсальный обработчик для документов
+	// HandleCallbackPrefix регистрирует обработчик колбэков, чьи данные начинаются с prefix
+	// (например, "page:" для "page:3"). Точные совпадения HandleCallback приоритетнее.
+	HandleCallbackPrefix(prefix string, handler HandlerFunc)
+	// UnhandleCommand удаляет обработчик команды, если он был зарегистрирован.
+	UnhandleCommand(command string)
+	// UnhandleCallback удаляет обработчик колбэка, если он был зарегистрирован.
+	UnhandleCallback(callbackData string)
+	// Reset удаляет все зарегистрированные обработчики и middleware.
+	Reset()
+	HandleDocument(handler HandlerFunc)  // универсальный обработчик для документов
 	HandleAnimation(handler HandlerFunc) // универсальный обработчик для анимаций
+	// HandleBusinessConnection регистрирует обработчик подключения/отключения бизнес-аккаунта.
+	HandleBusinessConnection(handler HandlerFunc)
+	// HandleBusinessMessage регистрирует обработчик новых и отредактированных бизнес-сообщений.
+	HandleBusinessMessage(handler HandlerFunc)
+	// HandleDeletedBusinessMessages регистрирует обработчик удаления сообщений в бизнес-аккаунте.
+	HandleDeletedBusinessMessages(handler HandlerFunc)
+	// HandleChatJoinRequest регистрирует обработчик заявок на вступление в чат.
+	HandleChatJoinRequest(handler HandlerFunc)
+	// Use регистрирует middleware, применяемые только к обновлениям указанного вида.
+	// Первый middleware в списке оборачивает последующие.
+	Use(updateType UpdateType, mws ...Middleware)
 	// Route определяет, какой обработчик должен обработать переданное обновление.
 	Route(update Update) error
 }
 
 // simpleRouter – простая реализация роутера.
+// Регистрация обработчиков и маршрутизация могут выполняться одновременно:
+// все поля с обработчиками защищены mu, а сами обработчики вызываются без блокировки.
 type simpleRouter struct {
+	mu               sync.RWMutex
 	commandHandlers  map[string]HandlerFunc
 	callbackHandlers map[string]HandlerFunc
-	documentHandler   HandlerFunc // единый обработчик для документов
-	animationHandler  HandlerFunc // единый обработчик для анимаций
-	logger           Logger
+	// callbackPrefixHandlers – обработчики колбэков по префиксу данных.
+	callbackPrefixHandlers map[string]HandlerFunc
+	// commandDescriptions – описания команд, заданные HandleCommandWithDescription.
+	commandDescriptions map[string]string
+	documentHandler     HandlerFunc // единый обработчик для документов
+	animationHandler    HandlerFunc // единый обработчик для анимаций
+	// обработчики обновлений бизнес-аккаунтов
+	businessConnectionHandler      HandlerFunc
+	businessMessageHandler         HandlerFunc
+	deletedBusinessMessagesHandler HandlerFunc
+	chatJoinRequestHandler         HandlerFunc
+	// middleware, привязанные к виду обновления
+	middlewares map[UpdateType][]Middleware
+	logger      Logger
 }
 
 // NewRouter создаёт новый экземпляр роутера с использованием переданного логгера.
 func NewRouter(logger Logger) Router {
 	return &simpleRouter{
-		commandHandlers:  make(map[string]HandlerFunc),
-		callbackHandlers: make(map[string]HandlerFunc),
-		logger:           logger,
+		commandHandlers:        make(map[string]HandlerFunc),
+		callbackHandlers:       make(map[string]HandlerFunc),
+		callbackPrefixHandlers: make(map[string]HandlerFunc),
+		commandDescriptions:    make(map[string]string),
+		middlewares:            make(map[UpdateType][]Middleware),
+		logger:                 logger,
 	}
 }
 
 // HandleCommand регистрирует обработчик для указанной команды.
 func (r *simpleRouter) HandleCommand(command string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.commandHandlers[command] = handler
 	r.logger.Debug("Registered command handler", Field{"command", command})
 }
 
+// HandleCommandWithDescription регистрирует обработчик команды и сохраняет её описание.
+func (r *simpleRouter) HandleCommandWithDescription(command, description string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commandHandlers[command] = handler
+	r.commandDescriptions[command] = description
+	r.logger.Debug("Registered command handler", Field{"command", command}, Field{"description", description})
+}
+
+// Commands возвращает команды, зарегистрированные с описанием, без ведущего "/".
+func (r *simpleRouter) Commands() []BotCommand {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	commands := make([]BotCommand, 0, len(r.commandDescriptions))
+	for command, description := range r.commandDescriptions {
+		commands = append(commands, BotCommand{
+			Command:     strings.TrimPrefix(command, "/"),
+			Description: description,
+		})
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Command < commands[j].Command })
+	return commands
+}
+
+// RegisterWithTelegram передаёт Commands() в меню команд бота.
+func (r *simpleRouter) RegisterWithTelegram(ctx context.Context, api BotAPI) error {
+	return api.SetMyCommands(ctx, r.Commands())
+}
+
 // HandleCallback регистрирует обработчик для указанного callback-данных.
 func (r *simpleRouter) HandleCallback(callbackData string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.callbackHandlers[callbackData] = handler
 	r.logger.Debug("Registered callback handler", Field{"callback_data", callbackData})
 }
 
+// HandleCallbackPrefix регистрирует обработчик для callback-данных, начинающихся с prefix.
+// Если подходят несколько префиксов, выбирается самый длинный.
+func (r *simpleRouter) HandleCallbackPrefix(prefix string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbackPrefixHandlers[prefix] = handler
+	r.logger.Debug("Registered callback prefix handler", Field{"prefix", prefix})
+}
+
+// UnhandleCommand удаляет обработчик указанной команды.
+func (r *simpleRouter) UnhandleCommand(command string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.commandHandlers[command]; !exists {
+		r.logger.Debug("No command handler to remove", Field{"command", command})
+		return
+	}
+	delete(r.commandHandlers, command)
+	delete(r.commandDescriptions, command)
+	r.logger.Debug("Removed command handler", Field{"command", command})
+}
+
+// UnhandleCallback удаляет обработчик указанных callback-данных.
+func (r *simpleRouter) UnhandleCallback(callbackData string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.callbackHandlers[callbackData]; !exists {
+		r.logger.Debug("No callback handler to remove", Field{"callback_data", callbackData})
+		return
+	}
+	delete(r.callbackHandlers, callbackData)
+	r.logger.Debug("Removed callback handler", Field{"callback_data", callbackData})
+}
+
+// Reset возвращает роутер в исходное состояние, как после NewRouter.
+func (r *simpleRouter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commandHandlers = make(map[string]HandlerFunc)
+	r.callbackHandlers = make(map[string]HandlerFunc)
+	r.callbackPrefixHandlers = make(map[string]HandlerFunc)
+	r.commandDescriptions = make(map[string]string)
+	r.middlewares = make(map[UpdateType][]Middleware)
+	r.documentHandler = nil
+	r.animationHandler = nil
+	r.businessConnectionHandler = nil
+	r.businessMessageHandler = nil
+	r.deletedBusinessMessagesHandler = nil
+	r.chatJoinRequestHandler = nil
+	r.logger.Debug("Router reset")
+}
+
 func (r *simpleRouter) HandleDocument(handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.documentHandler = handler
 	r.logger.Debug("Registered document handler")
 }
 
 func (r *simpleRouter) HandleAnimation(handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.animationHandler = handler
 	r.logger.Debug("Registered animation handler")
 }
 
-// Route выполняет маршрутизацию обновления.
-// Если обновление содержит сообщение с командой, ищется соответствующий обработчик.
-// Обработчик вызывается в блоке с механизмом перехвата паники.
-/*
-func (r *simpleRouter) Route(update Update) error {
-	var err error
+func (r *simpleRouter) HandleBusinessConnection(handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.businessConnectionHandler = handler
+	r.logger.Debug("Registered business connection handler")
+}
 
-	// Обработка текстового сообщения (например, команды, начинающиеся с '/')
-	if update.Message != nil {
-		text := update.Message.Text
-		if len(text) > 0 && text[0] == '/' {
-			if handler, exists := r.commandHandlers[text]; exists {
-				// Вызов обработчика в защищённом блоке с перехватом паники.
-				WithRecovery(r.logger, func() {
-					err = handler(update)
-				})
-				if err != nil {
-					r.logger.Error("Error handling command",
-						Field{"command", text},
-						Field{"error", err},
-					)
-					return err
-				}
-				r.logger.Info("Handled command successfully", Field{"command", text})
-			} else {
-				r.logger.Warn("No handler registered for command", Field{"command", text})
-			}
-		} else {
-			r.logger.Debug("Received message without command", Field{"text", text})
-		}
-	}
+func (r *simpleRouter) HandleBusinessMessage(handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.businessMessageHandler = handler
+	r.logger.Debug("Registered business message handler")
+}
 
-	// Если в будущем понадобится обрабатывать callback'и, можно добавить обработку аналогичным образом:
-		if update.CallbackQuery != nil {
-			data := update.CallbackQuery.Data
-			if handler, exists := r.callbackHandlers[data]; exists {
-				WithRecovery(r.logger, func() {
-					err = handler(update)
-				})
-				if err != nil {
-					r.logger.Error("Error handling callback",
-						Field{"callback_data", data},
-						Field{"error", err},
-					)
-					return err
-				}
-				r.logger.Info("Handled callback successfully", Field{"callback_data", data})
-			} else {
-				r.logger.Warn("No handler registered for callback", Field{"callback_data", data})
-			}
-		}
+func (r *simpleRouter) HandleDeletedBusinessMessages(handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deletedBusinessMessagesHandler = handler
+	r.logger.Debug("Registered deleted business messages handler")
+}
 
-	return nil
+func (r *simpleRouter) HandleChatJoinRequest(handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chatJoinRequestHandler = handler
+	r.logger.Debug("Registered chat join request handler")
 }
-*/
+
+// Use добавляет middleware для обновлений указанного вида.
+func (r *simpleRouter) Use(updateType UpdateType, mws ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares[updateType] = append(r.middlewares[updateType], mws...)
+	r.logger.Debug("Registered middleware", Field{"update_type", updateType}, Field{"count", len(mws)})
+}
+
+// Route выполняет маршрутизацию обновления, предварительно оборачивая её
+// в цепочку middleware, зарегистрированных для вида этого обновления.
 func (r *simpleRouter) Route(update Update) error {
+	handler := HandlerFunc(r.route)
+	r.mu.RLock()
+	mws := r.middlewares[update.Type()]
+	r.mu.RUnlock()
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler(update)
+}
+
+// route выполняет маршрутизацию обновления.
+// Команды в сообщениях ищутся по первому токену текста, колбэки – по callback_data.
+// Обработчик вызывается в блоке с механизмом перехвата паники.
+func (r *simpleRouter) route(update Update) error {
 	var err error
+	logger := LoggerWithCorrelation(r.logger, update.Context())
 
 	if update.Message != nil {
 		text := update.Message.Text
 		if len(text) > 0 && text[0] == '/' {
-			if handler, exists := r.commandHandlers[text]; exists {
-				WithRecovery(r.logger, func() {
+			// Обработчик ищется по первому токену, поэтому "/add x y" попадает в обработчик "/add".
+			command, _ := ParseCommand(text)
+			if handler, exists := r.commandHandler(command); exists {
+				WithRecovery(logger, func() {
 					err = handler(update)
 				})
 				if err != nil {
-					r.logger.Error("Error handling command", Field{"command", text}, Field{"error", err})
+					logger.Error("Error handling command", Field{"command", command}, Field{"error", err})
 					return err
 				}
-				r.logger.Info("Handled command successfully", Field{"command", text})
+				logger.Info("Handled command successfully", Field{"command", command})
 			} else {
-				r.logger.Warn("No handler registered for command", Field{"command", text})
+				logger.Warn("No handler registered for command", Field{"command", command})
 			}
 		} else {
 			// Если сообщение содержит документ
-			if update.Message.Document != nil && r.documentHandler != nil {
-				WithRecovery(r.logger, func() {
-					err = r.documentHandler(update)
+			documentHandler := r.handler(&r.documentHandler)
+			animationHandler := r.handler(&r.animationHandler)
+			if update.Message.Document != nil && documentHandler != nil {
+				WithRecovery(logger, func() {
+					err = documentHandler(update)
 				})
 				if err != nil {
-					r.logger.Error("Error handling document", Field{"error", err})
+					logger.Error("Error handling document", Field{"error", err})
 					return err
 				}
-			} else if update.Message.Animation != nil && r.animationHandler != nil {
-				WithRecovery(r.logger, func() {
-					err = r.animationHandler(update)
+			} else if update.Message.Animation != nil && animationHandler != nil {
+				WithRecovery(logger, func() {
+					err = animationHandler(update)
 				})
 				if err != nil {
-					r.logger.Error("Error handling animation", Field{"error", err})
+					logger.Error("Error handling animation", Field{"error", err})
 					return err
 				}
 			} else {
 				// Обработка других типов сообщений (видео, аудио, контакты, местоположение и т.д.)
-				r.logger.Debug("Received message without specific handler", Field{"text", text})
+				logger.Debug("Received message without specific handler", Field{"text", text})
+			}
+		}
+	}
+
+	if update.CallbackQuery != nil {
+		data := update.CallbackQuery.Data
+		if handler, exists := r.callbackHandler(data); exists {
+			WithRecovery(logger, func() {
+				err = handler(update)
+			})
+			if err != nil {
+				logger.Error("Error handling callback", Field{"callback_data", data}, Field{"error", err})
+				return err
 			}
+			logger.Info("Handled callback successfully", Field{"callback_data", data})
+		} else {
+			logger.Warn("No handler registered for callback", Field{"callback_data", data})
+		}
+	}
+
+	// Обновления бизнес-аккаунтов
+	if update.BusinessConnection != nil {
+		if err = r.callHandler("business connection", r.handler(&r.businessConnectionHandler), update); err != nil {
+			return err
+		}
+	}
+	if update.BusinessMessage != nil || update.EditedBusinessMessage != nil {
+		if err = r.callHandler("business message", r.handler(&r.businessMessageHandler), update); err != nil {
+			return err
+		}
+	}
+	if update.DeletedBusinessMessages != nil {
+		if err = r.callHandler("deleted business messages", r.handler(&r.deletedBusinessMessagesHandler), update); err != nil {
+			return err
+		}
+	}
+	if update.ChatJoinRequest != nil {
+		if err = r.callHandler("chat join request", r.handler(&r.chatJoinRequestHandler), update); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// commandHandler возвращает обработчик команды под блокировкой чтения.
+func (r *simpleRouter) commandHandler(command string) (HandlerFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, exists := r.commandHandlers[command]
+	return handler, exists
+}
+
+// callbackHandler возвращает обработчик колбэка под блокировкой чтения: обработчик
+// точного совпадения, а при его отсутствии – обработчик самого длинного подходящего префикса.
+func (r *simpleRouter) callbackHandler(data string) (HandlerFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if handler, exists := r.callbackHandlers[data]; exists {
+		return handler, true
+	}
+	var (
+		handler HandlerFunc
+		longest = -1
+	)
+	for prefix, h := range r.callbackPrefixHandlers {
+		if len(prefix) > longest && strings.HasPrefix(data, prefix) {
+			handler, longest = h, len(prefix)
+		}
+	}
+	return handler, handler != nil
+}
+
+// handler читает поле-обработчик роутера под блокировкой чтения.
+func (r *simpleRouter) handler(field *HandlerFunc) HandlerFunc {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return *field
+}
+
+// callHandler вызывает обработчик с перехватом паники и логирует результат.
+// Если обработчик не зарегистрирован, обновление пропускается.
+func (r *simpleRouter) callHandler(kind string, handler HandlerFunc, update Update) error {
+	logger := LoggerWithCorrelation(r.logger, update.Context())
+	if handler == nil {
+		logger.Debug("No handler registered for update", Field{"kind", kind}, Field{"update_id", update.UpdateID})
+		return nil
+	}
+	var err error
+	WithRecovery(logger, func() {
+		err = handler(update)
+	})
+	if err != nil {
+		logger.Error("Error handling update", Field{"kind", kind}, Field{"error", err})
+		return err
+	}
+	return nil
+}
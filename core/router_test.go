@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// newTestLogger возвращает логгер, который ничего не выводит.
+func newTestLogger() Logger {
+	return NewLogger(FatalLevel)
+}
+
+func TestRouterConcurrentRegistrationAndRouting(t *testing.T) {
+	router := NewRouter(newTestLogger())
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			command := fmt.Sprintf("/cmd%d", i)
+			router.HandleCommand(command, func(update Update) error { return nil })
+			router.UnhandleCommand(command)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			update := Update{UpdateID: i, Message: &Message{Text: fmt.Sprintf("/cmd%d", i)}}
+			if err := router.Route(update); err != nil {
+				t.Errorf("Route returned error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRouterReset(t *testing.T) {
+	router := NewRouter(newTestLogger())
+	called := false
+	router.HandleCommand("/start", func(update Update) error {
+		called = true
+		return io.EOF
+	})
+	router.Reset()
+	if err := router.Route(Update{Message: &Message{Text: "/start"}}); err != nil {
+		t.Fatalf("unexpected error after Reset: %v", err)
+	}
+	if called {
+		t.Fatal("handler was called after Reset")
+	}
+}
+
+func TestRouteChatJoinRequest(t *testing.T) {
+	r := NewRouter(newTestLogger())
+	var got *ChatJoinRequest
+	r.HandleChatJoinRequest(func(update Update) error {
+		got = update.ChatJoinRequest
+		return nil
+	})
+	update := Update{UpdateID: 1, ChatJoinRequest: &ChatJoinRequest{Chat: Chat{ID: -100}, From: User{ID: 5}}}
+	if err := r.Route(update); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if got == nil || got.Chat.ID != -100 {
+		t.Fatalf("join request handler not called: %+v", got)
+	}
+	if update.Type() != UpdateTypeChatJoinRequest || update.EffectiveUser().ID != 5 {
+		t.Errorf("unexpected type %q or user %+v", update.Type(), update.EffectiveUser())
+	}
+}
+
+func TestRouteBusinessConnection(t *testing.T) {
+	r := NewRouter(newTestLogger())
+	var got *BusinessConnection
+	r.HandleBusinessConnection(func(update Update) error {
+		got = update.BusinessConnection
+		return nil
+	})
+	update := Update{UpdateID: 1, BusinessConnection: &BusinessConnection{ID: "bc1", User: User{ID: 5}, IsEnabled: true}}
+	if err := r.Route(update); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if got == nil || got.ID != "bc1" {
+		t.Fatalf("business connection handler not called: %+v", got)
+	}
+	if update.Type() != UpdateTypeBusinessConnection || update.EffectiveUser().ID != 5 {
+		t.Errorf("unexpected type %q or user %+v", update.Type(), update.EffectiveUser())
+	}
+}
+
+func TestRouteBusinessMessage(t *testing.T) {
+	r := NewRouter(newTestLogger())
+	var got []UpdateType
+	r.HandleBusinessMessage(func(update Update) error {
+		got = append(got, update.Type())
+		return nil
+	})
+	msg := &Message{MessageID: 3, From: &User{ID: 5}, Chat: Chat{ID: 77}, Text: "hi"}
+	updates := []Update{
+		{UpdateID: 1, BusinessMessage: msg},
+		{UpdateID: 2, EditedBusinessMessage: msg},
+	}
+	for _, update := range updates {
+		if err := r.Route(update); err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+		if update.EffectiveChat().ID != 77 || update.EffectiveUser().ID != 5 {
+			t.Errorf("update %d: unexpected chat %+v or user %+v", update.UpdateID, update.EffectiveChat(), update.EffectiveUser())
+		}
+	}
+	if len(got) != 2 || got[0] != UpdateTypeBusinessMessage || got[1] != UpdateTypeEditedBusinessMessage {
+		t.Errorf("handled %v, want [%s %s]", got, UpdateTypeBusinessMessage, UpdateTypeEditedBusinessMessage)
+	}
+}
+
+func TestRouteCallbackQuery(t *testing.T) {
+	r := NewRouter(newTestLogger())
+	var got string
+	r.HandleCallback("confirm", func(update Update) error {
+		got = update.CallbackQuery.ID
+		return nil
+	})
+	update := Update{UpdateID: 1, CallbackQuery: &CallbackQuery{ID: "cb1", From: User{ID: 5}, Data: "confirm"}}
+	if err := r.Route(update); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if got != "cb1" {
+		t.Fatalf("callback handler not called, got %q", got)
+	}
+	if err := r.Route(Update{UpdateID: 2, CallbackQuery: &CallbackQuery{ID: "cb2", Data: "cancel"}}); err != nil {
+		t.Errorf("unhandled callback returned %v", err)
+	}
+}
+
+func TestRouteCallbackPrefix(t *testing.T) {
+	r := NewRouter(newTestLogger())
+	var got string
+	handler := func(name string) HandlerFunc {
+		return func(update Update) error {
+			got = name + " " + update.CallbackQuery.Data
+			return nil
+		}
+	}
+	r.HandleCallbackPrefix("page:", handler("page"))
+	r.HandleCallbackPrefix("page:admin:", handler("admin"))
+	r.HandleCallback("page:1", handler("first"))
+
+	cases := map[string]string{
+		"page:3":       "page page:3",
+		"page:admin:2": "admin page:admin:2",
+		"page:1":       "first page:1",
+	}
+	for data, want := range cases {
+		got = ""
+		if err := r.Route(Update{CallbackQuery: &CallbackQuery{Data: data}}); err != nil {
+			t.Fatalf("Route(%q): %v", data, err)
+		}
+		if got != want {
+			t.Errorf("Route(%q) called %q, want %q", data, got, want)
+		}
+	}
+}
+
+func TestCommandsRegistryAndHelp(t *testing.T) {
+	router := NewRouter(newTestLogger())
+	noop := func(update Update) error { return nil }
+	router.HandleCommandWithDescription("/start", "Начать работу", noop)
+	router.HandleCommandWithDescription("/help", "Список команд", noop)
+	router.HandleCommand("/hidden", noop)
+	router.HandleCommandWithDescription("/old", "Устарела", noop)
+	router.UnhandleCommand("/old")
+
+	commands := router.Commands()
+	want := []BotCommand{{"help", "Список команд"}, {"start", "Начать работу"}}
+	if fmt.Sprint(commands) != fmt.Sprint(want) {
+		t.Fatalf("Commands() = %v, want %v", commands, want)
+	}
+	if got := HelpText(commands); got != "/help – Список команд\n/start – Начать работу" {
+		t.Errorf("unexpected help text: %q", got)
+	}
+}
@@ -0,0 +1,30 @@
+package core
+
+import "context"
+
+// UpdateSource – источник обновлений: поллер (NewPoller) или вебхук (webhooks.NewWebhookManager).
+// Updates запускает получение обновлений и возвращает канал, который закрывается
+// после отмены ctx. Каждое обновление уже содержит контекст с correlation ID.
+type UpdateSource interface {
+	Updates(ctx context.Context) (<-chan Update, error)
+}
+
+// Serve получает обновления из source и по очереди передаёт их в router.Route, пока канал
+// не будет закрыт. Ошибки и паника обработчиков логируются и не прерывают обработку.
+// Возвращает ошибку запуска source или ctx.Err() после отмены ctx.
+func Serve(ctx context.Context, source UpdateSource, router Router, logger Logger) error {
+	updates, err := source.Updates(ctx)
+	if err != nil {
+		return err
+	}
+	for update := range updates {
+		logger := LoggerWithCorrelation(logger, update.Context())
+		WithRecovery(logger, func() {
+			err = router.Route(update)
+		})
+		if err != nil {
+			logger.Error("Error routing update", Field{"update_id", update.UpdateID}, Field{"error", err})
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// sliceSource – UpdateSource, отдающий заранее заданные обновления.
+type sliceSource []Update
+
+func (s sliceSource) Updates(ctx context.Context) (<-chan Update, error) {
+	updates := make(chan Update, len(s))
+	for _, update := range s {
+		updates <- update.WithContext(ctx)
+	}
+	close(updates)
+	return updates, nil
+}
+
+func TestServeRoutesAllUpdates(t *testing.T) {
+	r := NewRouter(newTestLogger())
+	var handled []string
+	r.HandleCallback("ok", func(update Update) error {
+		handled = append(handled, update.CallbackQuery.ID)
+		return nil
+	})
+	r.HandleCallback("fail", func(update Update) error {
+		handled = append(handled, update.CallbackQuery.ID)
+		return errors.New("boom")
+	})
+	source := sliceSource{
+		{UpdateID: 1, CallbackQuery: &CallbackQuery{ID: "a", Data: "fail"}},
+		{UpdateID: 2, CallbackQuery: &CallbackQuery{ID: "b", Data: "ok"}},
+	}
+	if err := Serve(context.Background(), source, r, newTestLogger()); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if len(handled) != 2 || handled[1] != "b" {
+		t.Errorf("handled = %v, want [a b]", handled)
+	}
+}
+
+func TestPollerUpdates(t *testing.T) {
+	api := &fakeUpdatesAPI{updates: []Update{{UpdateID: 7}}}
+	p := NewPoller(api, NewRouter(newTestLogger()), newTestLogger())
+	updates, err := p.Updates(context.Background())
+	if err != nil {
+		t.Fatalf("Updates: %v", err)
+	}
+	update := <-updates
+	if update.UpdateID != 7 || CorrelationIDFromContext(update.Context()) == "" || APIFromContext(update.Context()) == nil {
+		t.Errorf("unexpected update %+v", update)
+	}
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if _, ok := <-updates; ok {
+		t.Error("channel not closed after Stop")
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// FileUpload – локальный файл для отправки через multipart/form-data.
+// Передаётся в SendPhoto, SendDocument и SendAnimation вместо URL или file_id.
+type FileUpload struct {
+	// Name – имя файла, которое увидит получатель.
+	Name string
+	// Reader – содержимое файла.
+	Reader io.Reader
+}
+
+// NewFileUpload создаёт FileUpload с указанным именем из произвольного io.Reader.
+func NewFileUpload(name string, r io.Reader) FileUpload {
+	return FileUpload{Name: name, Reader: r}
+}
+
+// asFileUpload проверяет, является ли значение параметра локальным файлом.
+// *os.File оборачивается в FileUpload с базовым именем файла.
+func asFileUpload(v interface{}) (FileUpload, bool) {
+	switch f := v.(type) {
+	case FileUpload:
+		return f, true
+	case *FileUpload:
+		if f != nil {
+			return *f, true
+		}
+	case *os.File:
+		if f != nil {
+			return FileUpload{Name: filepath.Base(f.Name()), Reader: f}, true
+		}
+	}
+	return FileUpload{}, false
+}
+
+// sendMedia выполняет метод отправки медиа method. Если значение поля fileField – локальный
+// файл (см. asFileUpload), запрос отправляется через multipart/form-data, иначе – в JSON.
+func (b *botClient) sendMedia(ctx context.Context, method, fileField string, payload map[string]interface{}) error {
+	if upload, ok := asFileUpload(payload[fileField]); ok {
+		return b.callMultipart(ctx, method, payload, fileField, upload, nil)
+	}
+	return b.call(ctx, method, payload, nil)
+}
+
+// callMultipart выполняет метод Telegram API с телом multipart/form-data: файл upload
+// передаётся в поле fileField, остальные поля payload – как значения формы.
+// Строки и числа записываются как есть, остальные значения (разметка, сущности) – в JSON.
+func (b *botClient) callMultipart(ctx context.Context, method string, payload map[string]interface{}, fileField string, upload FileUpload, out interface{}) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range payload {
+		if key == fileField {
+			continue
+		}
+		field, err := formValue(value)
+		if err != nil {
+			b.logger.Error("Failed to encode "+method+" field", Field{"field", key}, Field{"error", err})
+			return err
+		}
+		if err := writer.WriteField(key, field); err != nil {
+			return err
+		}
+	}
+	part, err := writer.CreateFormFile(fileField, upload.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, upload.Reader); err != nil {
+		b.logger.Error("Failed to read "+method+" file", Field{"file", upload.Name}, Field{"error", err})
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s", b.apiURL, method)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, &body)
+	if err != nil {
+		b.logger.Error("Failed to create "+method+" request", Field{"error", err})
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return b.do(method, req, out)
+}
+
+// formValue преобразует значение payload в строку поля формы.
+func formValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case int:
+		return strconv.Itoa(val), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case ChatID:
+		return val.String(), nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
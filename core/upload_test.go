@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSendPhotoUploadsReaderAsMultipart(t *testing.T) {
+	var contentTypes []string
+	var fields map[string]string
+	var fileName, fileData string
+	api, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("ParseMultipartForm: %v", err)
+			}
+			fields = map[string]string{}
+			for key := range r.MultipartForm.Value {
+				fields[key] = r.FormValue(key)
+			}
+			file, header, err := r.FormFile("photo")
+			if err != nil {
+				t.Errorf("FormFile: %v", err)
+			} else {
+				data, _ := io.ReadAll(file)
+				fileName, fileData = header.Filename, string(data)
+			}
+		}
+		okHandler(w, r)
+	})
+
+	upload := NewFileUpload("cat.jpg", strings.NewReader("JPEG"))
+	if err := api.SendPhoto(context.Background(), ChatIDFromInt(42), upload, "кот", nil, WithParseMode(ParseModeHTML)); err != nil {
+		t.Fatalf("SendPhoto: %v", err)
+	}
+	if err := api.SendPhoto(context.Background(), ChatIDFromInt(42), "file-id", "", nil); err != nil {
+		t.Fatalf("SendPhoto: %v", err)
+	}
+
+	if len(contentTypes) != 2 {
+		t.Fatalf("got %d requests, want 2", len(contentTypes))
+	}
+	if !strings.HasPrefix(contentTypes[0], "multipart/form-data") {
+		t.Errorf("upload Content-Type = %q, want multipart/form-data", contentTypes[0])
+	}
+	if contentTypes[1] != "application/json" {
+		t.Errorf("file_id Content-Type = %q, want application/json", contentTypes[1])
+	}
+	if fileName != "cat.jpg" || fileData != "JPEG" {
+		t.Errorf("uploaded file = %q (%q), want cat.jpg (JPEG)", fileName, fileData)
+	}
+	if fields["chat_id"] != "42" || fields["caption"] != "кот" || fields["parse_mode"] != ParseModeHTML {
+		t.Errorf("unexpected form fields: %v", fields)
+	}
+}
+
+func TestSendAnimationAndDocumentUploadFiles(t *testing.T) {
+	uploaded := map[string]string{}
+	api, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		field := strings.TrimPrefix(r.URL.Path, "/botTEST_TOKEN/send")
+		field = strings.ToLower(field)
+		file, header, err := r.FormFile(field)
+		if err != nil {
+			t.Errorf("%s: FormFile(%q): %v", r.URL.Path, field, err)
+		} else {
+			data, _ := io.ReadAll(file)
+			uploaded[field] = header.Filename + ":" + string(data)
+		}
+		okHandler(w, r)
+	})
+	ctx := context.Background()
+	if err := api.SendAnimation(ctx, ChatIDFromInt(42), NewFileUpload("fun.gif", strings.NewReader("GIF")), "", nil); err != nil {
+		t.Fatalf("SendAnimation: %v", err)
+	}
+	if err := api.SendDocument(ctx, ChatIDFromInt(42), &FileUpload{Name: "a.pdf", Reader: strings.NewReader("PDF")}, "", nil); err != nil {
+		t.Fatalf("SendDocument: %v", err)
+	}
+	if uploaded["animation"] != "fun.gif:GIF" || uploaded["document"] != "a.pdf:PDF" {
+		t.Errorf("unexpected uploads: %v", uploaded)
+	}
+}
@@ -4,20 +4,85 @@ import (
 	"context"
         "crypto/rand"
         "encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf16"
 )
 
-// WithTimeoutAndCorrelation создает контекст с заданным таймаутом и добавляет correlation ID в контекст.
+// correlationIDKey – ключ контекста для correlation ID.
+type correlationIDKey struct{}
+
+// apiKey – ключ контекста для BotAPI, получившего обновление.
+type apiKey struct{}
+
+// WithTimeoutAndCorrelation создает контекст с заданным таймаутом и добавляет correlation ID в контекст,
+// если он ещё не задан в parent.
 func WithTimeoutAndCorrelation(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
-	// Можно расширить этот функционал, добавив генерацию correlation ID, если он еще не задан.
 	ctx, cancel := context.WithTimeout(parent, timeout)
-	// Например, если не установлен, можно установить в контекст:
-	if ctx.Value("correlation_id") == nil {
-		ctx = context.WithValue(ctx, "correlation_id", generateCorrelationID())
+	if CorrelationIDFromContext(ctx) == "" {
+		ctx = ContextWithCorrelationID(ctx, generateCorrelationID())
 	}
 	return ctx, cancel
 }
 
+// ContextWithCorrelationID возвращает копию ctx с указанным correlation ID.
+func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext возвращает correlation ID из контекста или пустую строку, если он не задан.
+func CorrelationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// NewCorrelationID генерирует новый correlation ID.
+func NewCorrelationID() string {
+	return generateCorrelationID()
+}
+
+// LoggerWithCorrelation возвращает логгер с полем correlation_id из контекста.
+// Если correlation ID в контексте нет, возвращается исходный логгер.
+func LoggerWithCorrelation(logger Logger, ctx context.Context) Logger {
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		return logger.WithFields(Field{"correlation_id", id})
+	}
+	return logger
+}
+
+// ContextWithAPI возвращает копию ctx с api. Поллер и вебхук (webhooks.WithBotAPI) добавляют
+// клиент в контекст каждого обновления, чтобы обработчики не захватывали его при регистрации.
+func ContextWithAPI(ctx context.Context, api BotAPI) context.Context {
+	return context.WithValue(ctx, apiKey{}, api)
+}
+
+// APIFromContext возвращает BotAPI, добавленный ContextWithAPI, или nil, если его нет:
+//
+//	router.HandleCommand("/start", func(update core.Update) error {
+//		api := core.APIFromContext(update.Context())
+//		return api.SendMessage(update.Context(), update.Message.Chat.ID, "Hi!")
+//	})
+func APIFromContext(ctx context.Context) BotAPI {
+	if ctx == nil {
+		return nil
+	}
+	api, _ := ctx.Value(apiKey{}).(BotAPI)
+	return api
+}
+
+// withNewCorrelationID привязывает к обновлению контекст, производный от ctx, с новым correlation ID.
+// Вызывается при получении обновления (поллинг или вебхук), чтобы все логи его обработки были связаны.
+func withNewCorrelationID(ctx context.Context, update Update) Update {
+	return update.WithContext(ContextWithCorrelationID(ctx, generateCorrelationID()))
+}
+
 // generateCorrelationID генерирует уникальную строку с использованием crypto/rand.
 func generateCorrelationID() string {
         b := make([]byte, 16)
@@ -27,4 +92,132 @@ func generateCorrelationID() string {
                 return "unknown-corr-id"
         }
         return hex.EncodeToString(b)
-}
\ No newline at end of file
+}
+
+// MaxMessageLength – максимальная длина текста сообщения в Telegram.
+const MaxMessageLength = 4096
+
+// SplitText разбивает текст на части не длиннее limit символов (рун).
+// Разрез выполняется по последнему переводу строки в пределах лимита, иначе по
+// последнему пробельному символу и только в крайнем случае посреди слова.
+// Многобайтовые символы никогда не разрываются.
+func SplitText(text string, limit int) []string {
+	var chunks []string
+	for _, span := range splitText([]rune(text), limit) {
+		chunks = append(chunks, span.text)
+	}
+	return chunks
+}
+
+// TextPart – часть текста, полученная SplitTextWithEntities.
+type TextPart struct {
+	Text string
+	// Entities – сущности, попавшие в часть, со смещениями относительно её начала.
+	Entities []MessageEntity
+}
+
+// SplitTextWithEntities разбивает текст так же, как SplitText, и распределяет entities
+// (смещения в единицах UTF-16, как в Telegram) по частям. Сущность, пересекающая границу
+// частей, делится между ними; сущности, попавшие на отброшенный разделитель, пропускаются.
+func SplitTextWithEntities(text string, entities []MessageEntity, limit int) []TextPart {
+	runes := []rune(text)
+	// utf16At[i] – смещение руны i в единицах UTF-16.
+	utf16At := make([]int, len(runes)+1)
+	for i, r := range runes {
+		utf16At[i+1] = utf16At[i] + utf16.RuneLen(r)
+	}
+	var parts []TextPart
+	for _, span := range splitText(runes, limit) {
+		partStart := utf16At[span.start]
+		partEnd := utf16At[span.start+len([]rune(span.text))]
+		part := TextPart{Text: span.text}
+		for _, e := range entities {
+			from, to := e.Offset, e.Offset+e.Length
+			if from < partStart {
+				from = partStart
+			}
+			if to > partEnd {
+				to = partEnd
+			}
+			if from >= to {
+				continue
+			}
+			e.Offset = from - partStart
+			e.Length = to - from
+			part.Entities = append(part.Entities, e)
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// textSpan – часть текста и индекс её первой руны в исходном тексте.
+type textSpan struct {
+	text  string
+	start int
+}
+
+// splitText выполняет разбиение для SplitText и SplitTextWithEntities.
+func splitText(runes []rune, limit int) []textSpan {
+	if limit <= 0 {
+		limit = MaxMessageLength
+	}
+	var spans []textSpan
+	start := 0
+	for len(runes) > limit {
+		cut := lastIndexRune(runes[:limit+1], func(r rune) bool { return r == '\n' })
+		if cut <= 0 {
+			cut = lastIndexRune(runes[:limit+1], unicode.IsSpace)
+		}
+		if cut <= 0 {
+			// Подходящей границы нет – режем ровно по лимиту.
+			spans = append(spans, textSpan{text: string(runes[:limit]), start: start})
+			runes = runes[limit:]
+			start += limit
+			continue
+		}
+		chunk := strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace)
+		if chunk != "" {
+			spans = append(spans, textSpan{text: chunk, start: start})
+		}
+		// Разделитель, по которому прошёл разрез, отбрасывается.
+		runes = runes[cut+1:]
+		start += cut + 1
+	}
+	if len(runes) > 0 {
+		spans = append(spans, textSpan{text: string(runes), start: start})
+	}
+	return spans
+}
+
+// lastIndexRune возвращает индекс последней руны, удовлетворяющей f, или -1.
+func lastIndexRune(runes []rune, f func(rune) bool) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if f(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// DefaultMaxResponseSize – ограничение размера тела ответа Bot API по умолчанию (10 МБ).
+const DefaultMaxResponseSize int64 = 10 << 20
+
+// ErrResponseTooLarge возвращается ReadLimited, если тело длиннее допустимого.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ReadLimited читает r целиком, но не более limit байт. Если данных больше,
+// возвращается ошибка ErrResponseTooLarge. При limit <= 0 ограничение не применяется.
+func ReadLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitTextShortText(t *testing.T) {
+	chunks := SplitText("hello", 10)
+	if len(chunks) != 1 || chunks[0] != "hello" {
+		t.Fatalf("unexpected chunks: %q", chunks)
+	}
+}
+
+func TestSplitTextPrefersLineBreaks(t *testing.T) {
+	text := "first line\nsecond line\nthird"
+	chunks := SplitText(text, 15)
+	want := []string{"first line", "second line", "third"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", chunks, want)
+	}
+}
+
+func TestSplitTextKeepsRunesIntact(t *testing.T) {
+	// Строка без пробелов из многобайтовых символов режется строго по лимиту.
+	text := strings.Repeat("я", 25)
+	chunks := SplitText(text, 10)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	for _, c := range chunks {
+		if !utf8.ValidString(c) {
+			t.Errorf("chunk is not valid UTF-8: %q", c)
+		}
+		if n := utf8.RuneCountInString(c); n > 10 {
+			t.Errorf("chunk exceeds limit: %d runes", n)
+		}
+	}
+	if strings.Join(chunks, "") != text {
+		t.Errorf("chunks do not reassemble the original text")
+	}
+}
+
+func TestSplitTextWordBoundary(t *testing.T) {
+	chunks := SplitText("alpha beta gamma", 11)
+	want := []string{"alpha beta", "gamma"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", chunks, want)
+	}
+}
+
+func TestSplitTextWithEntitiesRebasesOffsets(t *testing.T) {
+	// Эмодзи занимает две единицы UTF-16, поэтому смещения считаются не в рунах.
+	text := "😀 bold words\nnext line"
+	entities := []MessageEntity{
+		{Type: "bold", Offset: 3, Length: 4},
+		{Type: "italic", Offset: 8, Length: 10},
+		{Type: "code", Offset: 19, Length: 4},
+	}
+	parts := SplitTextWithEntities(text, entities, 12)
+	if len(parts) != 2 || parts[0].Text != "😀 bold words" || parts[1].Text != "next line" {
+		t.Fatalf("unexpected parts: %+v", parts)
+	}
+	want := [][]MessageEntity{
+		{{Type: "bold", Offset: 3, Length: 4}, {Type: "italic", Offset: 8, Length: 5}},
+		{{Type: "italic", Offset: 0, Length: 4}, {Type: "code", Offset: 5, Length: 4}},
+	}
+	for i, part := range parts {
+		if !reflect.DeepEqual(part.Entities, want[i]) {
+			t.Errorf("part %d entities = %+v, want %+v", i, part.Entities, want[i])
+		}
+	}
+	if got := parts[0].Entities[0].Text(parts[0].Text); got != "bold" {
+		t.Errorf("bold entity covers %q", got)
+	}
+	if got := parts[1].Entities[1].Text(parts[1].Text); got != "line" {
+		t.Errorf("code entity covers %q", got)
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	data, err := ReadLimited(strings.NewReader("hello"), 5)
+	if err != nil || string(data) != "hello" {
+		t.Errorf("got %q, %v", data, err)
+	}
+	if _, err := ReadLimited(strings.NewReader("hello!"), 5); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+	if data, err := ReadLimited(strings.NewReader("hello!"), 0); err != nil || len(data) != 6 {
+		t.Errorf("unlimited read: got %q, %v", data, err)
+	}
+}
+
+func TestAPIFromContext(t *testing.T) {
+	if api := APIFromContext(context.Background()); api != nil {
+		t.Errorf("expected nil API, got %v", api)
+	}
+	api := &fakeUpdatesAPI{}
+	if got := APIFromContext(ContextWithAPI(context.Background(), api)); got != api {
+		t.Errorf("got %v, want %v", got, api)
+	}
+}
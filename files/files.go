@@ -2,7 +2,9 @@ package files
 
 import (
         "bytes"
+        "context"
         "encoding/json"
+        "errors"
         "fmt"
         "io"
         "io/ioutil"
@@ -10,6 +12,8 @@ import (
         "net/http"
         "os"
         "path/filepath"
+        "sync"
+        "time"
 
         "github.com/VVolf8/go-telegram-bot/core"
 )
@@ -25,35 +29,248 @@ type File struct {
 type FileManager interface {
         UploadFile(chatID int64, filePath, caption string) error
         DownloadFile(fileID string) ([]byte, error)
+        // DownloadFileTo скачивает файл в dst, при обрыве соединения докачивая его с помощью заголовка Range.
+        // Возвращает число байт, записанных в dst.
+        DownloadFileTo(ctx context.Context, fileID string, dst io.WriteSeeker) (int64, error)
+        // UploadOnce загружает файл как документ в служебный чат (WithStorageChat) и возвращает
+        // file_id, переиспользуемый в SendDocument.
+        UploadOnce(ctx context.Context, filePath string) (string, error)
+        // DownloadChatPhoto скачивает фотографию чата: большую (640x640), если big, иначе маленькую (160x160).
+        DownloadChatPhoto(ctx context.Context, chatID core.ChatID, big bool) ([]byte, error)
+}
+
+// ErrNoStorageChat возвращается UploadOnce, если служебный чат не задан опцией WithStorageChat.
+var ErrNoStorageChat = errors.New("storage chat is not configured")
+
+// ErrNoChatPhoto возвращается DownloadChatPhoto, если у чата нет фотографии.
+var ErrNoChatPhoto = errors.New("chat has no photo")
+
+const (
+        // DefaultBaseURL – адрес Bot API по умолчанию.
+        DefaultBaseURL = "https://api.telegram.org"
+        // DefaultDownloadRetries – число повторных попыток DownloadFileTo по умолчанию.
+        DefaultDownloadRetries = 3
+)
+
+// Option задаёт необязательные параметры FileManager.
+type Option func(*fileManager)
+
+// WithBaseURL задаёт адрес Bot API, например собственного (self-hosted) сервера.
+func WithBaseURL(baseURL string) Option {
+        return func(fm *fileManager) {
+                fm.baseURL = baseURL
+        }
+}
+
+// WithDownloadRetries задаёт число повторных попыток DownloadFileTo после сбоя.
+func WithDownloadRetries(retries int) Option {
+        return func(fm *fileManager) {
+                if retries >= 0 {
+                        fm.downloadRetries = retries
+                }
+        }
+}
+
+// WithMaxConcurrentTransfers ограничивает число одновременных загрузок и скачиваний.
+// Операции сверх лимита ожидают освобождения слота. По умолчанию ограничения нет.
+func WithMaxConcurrentTransfers(n int) Option {
+        return func(fm *fileManager) {
+                if n > 0 {
+                        fm.transfers = make(chan struct{}, n)
+                }
+        }
+}
+
+// WithStorageChat задаёт служебный чат (например, приватный канал бота), в который UploadOnce
+// загружает файлы для получения file_id.
+func WithStorageChat(chatID int64) Option {
+        return func(fm *fileManager) {
+                fm.storageChatID = chatID
+        }
+}
+
+// WithMaxResponseSize задаёт максимальный размер ответа Bot API (getFile, sendDocument) в байтах.
+// Само скачивание файла не ограничивается. Значение <= 0 снимает ограничение.
+func WithMaxResponseSize(n int64) Option {
+        return func(fm *fileManager) {
+                fm.maxResponseSize = n
+        }
+}
+
+// WithBotAPI задаёт клиент Bot API, через который FileManager вызывает методы, не связанные
+// с передачей файлов (например, getChat в DownloadChatPhoto). По умолчанию создаётся клиент
+// с токеном, HTTP-клиентом и адресом (WithBaseURL) FileManager.
+func WithBotAPI(api core.BotAPI) Option {
+        return func(fm *fileManager) {
+                fm.api = api
+        }
 }
 
 // fileManager – реализация FileManager.
 type fileManager struct {
         token      string
+        baseURL    string
         httpClient *http.Client
         logger     core.Logger
+        // api используется для вызовов Bot API, не связанных с передачей файлов.
+        api core.BotAPI
+        // downloadRetries и retryDelay управляют повторами DownloadFileTo.
+        downloadRetries int
+        retryDelay      time.Duration
+        // transfers – семафор одновременных передач; nil означает отсутствие ограничения.
+        transfers chan struct{}
+        // maxResponseSize ограничивает размер читаемых ответов Bot API.
+        maxResponseSize int64
+        // storageChatID, fileIDs и pendingUploads используются UploadOnce: fileIDs кэширует file_id
+        // по пути к файлу, pendingUploads хранит выполняющиеся загрузки.
+        storageChatID  int64
+        fileIDsMu      sync.Mutex
+        fileIDs        map[string]string
+        pendingUploads map[string]*pendingUpload
+}
+
+// acquire занимает слот передачи, ожидая его освобождения или отмены ctx.
+// Возвращаемую функцию нужно вызвать по завершении передачи.
+func (fm *fileManager) acquire(ctx context.Context) (release func(), err error) {
+        if fm.transfers == nil {
+                return func() {}, nil
+        }
+        select {
+        case fm.transfers <- struct{}{}:
+                return func() { <-fm.transfers }, nil
+        case <-ctx.Done():
+                return nil, ctx.Err()
+        }
 }
 
 // NewFileManager создаёт новый FileManager с заданным токеном, логгером и HTTP-клиентом.
-func NewFileManager(token string, logger core.Logger, httpClient *http.Client) FileManager {
+// Дополнительные параметры передаются через opts.
+func NewFileManager(token string, logger core.Logger, httpClient *http.Client, opts ...Option) FileManager {
         if httpClient == nil {
                 httpClient = &http.Client{}
         }
-        return &fileManager{
-                token:      token,
-                httpClient: httpClient,
-                logger:     logger,
+        fm := &fileManager{
+                token:           token,
+                baseURL:         DefaultBaseURL,
+                httpClient:      httpClient,
+                logger:          logger,
+                downloadRetries: DefaultDownloadRetries,
+                retryDelay:      time.Second,
+                maxResponseSize: core.DefaultMaxResponseSize,
+                fileIDs:         make(map[string]string),
+                pendingUploads:  make(map[string]*pendingUpload),
+        }
+        for _, opt := range opts {
+                opt(fm)
         }
+        if fm.api == nil {
+                fm.api = core.NewBotClient(token, logger, httpClient,
+                        core.WithBaseURL(fm.baseURL),
+                        core.WithMaxResponseSize(fm.maxResponseSize),
+                )
+        }
+        return fm
 }
 
 // UploadFile загружает файл (например, документ) в Telegram, отправляя его через multipart/form-data.
 func (fm *fileManager) UploadFile(chatID int64, filePath, caption string) error {
-        endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendDocument", fm.token)
+        if _, err := fm.postDocument(context.Background(), chatID, filePath, caption); err != nil {
+                return err
+        }
+        fm.logger.Info("File uploaded successfully", core.Field{"chat_id", chatID}, core.Field{"file", filePath})
+        return nil
+}
+
+// UploadOnce загружает файл методом sendDocument в служебный чат, заданный WithStorageChat,
+// и возвращает file_id документа. Telegram принимает такой file_id только там, где ожидается
+// документ: в SendDocument и в элементах InputMediaDocument для SendMediaGroup.
+// Результат кэшируется по пути к файлу: повторные вызовы для того же файла не обращаются к API,
+// а одновременные вызовы дожидаются единственной загрузки. Загрузка не зависит от отмены
+// контекста вызывающего: отменивший ctx получает ctx.Err(), а остальные – её результат.
+func (fm *fileManager) UploadOnce(ctx context.Context, filePath string) (string, error) {
+        if fm.storageChatID == 0 {
+                return "", ErrNoStorageChat
+        }
+        fm.fileIDsMu.Lock()
+        if fileID, ok := fm.fileIDs[filePath]; ok {
+                fm.fileIDsMu.Unlock()
+                return fileID, nil
+        }
+        pending, ok := fm.pendingUploads[filePath]
+        if !ok {
+                pending = &pendingUpload{done: make(chan struct{})}
+                fm.pendingUploads[filePath] = pending
+                go fm.runUpload(context.WithoutCancel(ctx), filePath, pending)
+        }
+        fm.fileIDsMu.Unlock()
+
+        select {
+        case <-pending.done:
+                return pending.fileID, pending.err
+        case <-ctx.Done():
+                return "", ctx.Err()
+        }
+}
+
+// runUpload выполняет загрузку pending и сохраняет file_id в кэше при успехе.
+func (fm *fileManager) runUpload(ctx context.Context, filePath string, pending *pendingUpload) {
+        pending.fileID, pending.err = fm.uploadToStorage(ctx, filePath)
+
+        fm.fileIDsMu.Lock()
+        if pending.err == nil {
+                fm.fileIDs[filePath] = pending.fileID
+        }
+        delete(fm.pendingUploads, filePath)
+        fm.fileIDsMu.Unlock()
+        close(pending.done)
+}
+
+// pendingUpload – загрузка UploadOnce, которая ещё выполняется. fileID и err заполняются
+// до закрытия done.
+type pendingUpload struct {
+        done   chan struct{}
+        fileID string
+        err    error
+}
+
+// uploadToStorage отправляет файл в служебный чат и возвращает file_id документа.
+func (fm *fileManager) uploadToStorage(ctx context.Context, filePath string) (string, error) {
+        respBody, err := fm.postDocument(ctx, fm.storageChatID, filePath, "")
+        if err != nil {
+                return "", err
+        }
+        var result struct {
+                Ok     bool `json:"ok"`
+                Result struct {
+                        Document *File `json:"document"`
+                } `json:"result"`
+        }
+        if err := json.Unmarshal(respBody, &result); err != nil {
+                fm.logger.Error("Failed to unmarshal upload response", core.Field{"error", err})
+                return "", err
+        }
+        if !result.Ok || result.Result.Document == nil || result.Result.Document.FileID == "" {
+                return "", errors.New("upload response contains no file_id")
+        }
+        fileID := result.Result.Document.FileID
+        fm.logger.Info("File uploaded to storage chat", core.Field{"file", filePath}, core.Field{"file_id", fileID})
+        return fileID, nil
+}
+
+// postDocument отправляет файл методом sendDocument через multipart/form-data и возвращает тело ответа.
+func (fm *fileManager) postDocument(ctx context.Context, chatID int64, filePath, caption string) ([]byte, error) {
+        release, err := fm.acquire(ctx)
+        if err != nil {
+                return nil, err
+        }
+        defer release()
+
+        endpoint := fmt.Sprintf("%s/bot%s/sendDocument", fm.baseURL, fm.token)
 
         file, err := os.Open(filePath)
         if err != nil {
                 fm.logger.Error("Failed to open file", core.Field{"error", err}, core.Field{"filePath", filePath})
-                return err
+                return nil, err
         }
         defer file.Close()
 
@@ -65,7 +282,7 @@ func (fm *fileManager) UploadFile(chatID int64, filePath, caption string) error
         err = writer.WriteField("chat_id", fmt.Sprintf("%d", chatID))
         if err != nil {
                 fm.logger.Error("Failed to write chat_id field", core.Field{"error", err})
-                return err
+                return nil, err
         }
 
         // Если указан caption, добавляем его.
@@ -73,7 +290,7 @@ func (fm *fileManager) UploadFile(chatID int64, filePath, caption string) error
                 err = writer.WriteField("caption", caption)
                 if err != nil {
                         fm.logger.Error("Failed to write caption field", core.Field{"error", err})
-                        return err
+                        return nil, err
                 }
         }
 
@@ -81,24 +298,24 @@ func (fm *fileManager) UploadFile(chatID int64, filePath, caption string) error
         part, err := writer.CreateFormFile("document", filepath.Base(filePath))
         if err != nil {
                 fm.logger.Error("Failed to create form file", core.Field{"error", err})
-                return err
+                return nil, err
         }
         _, err = io.Copy(part, file)
         if err != nil {
                 fm.logger.Error("Failed to copy file content", core.Field{"error", err})
-                return err
+                return nil, err
         }
 
         // Завершаем запись multipart-формы.
         if err = writer.Close(); err != nil {
                 fm.logger.Error("Failed to close writer", core.Field{"error", err})
-                return err
+                return nil, err
         }
 
-        req, err := http.NewRequest("POST", endpoint, &requestBody)
+        req, err := http.NewRequestWithContext(ctx, "POST", endpoint, &requestBody)
         if err != nil {
                 fm.logger.Error("Failed to create upload request", core.Field{"error", err})
-                return err
+                return nil, err
         }
         req.Header.Set("Content-Type", writer.FormDataContentType())
 
@@ -108,14 +325,14 @@ func (fm *fileManager) UploadFile(chatID int64, filePath, caption string) error
         })
         if err != nil {
                 fm.logger.Error("Error during file upload", core.Field{"error", err})
-                return err
+                return nil, err
         }
         defer resp.Body.Close()
 
-        respBody, err := ioutil.ReadAll(resp.Body)
+        respBody, err := core.ReadLimited(resp.Body, fm.maxResponseSize)
         if err != nil {
                 fm.logger.Error("Failed to read upload response", core.Field{"error", err})
-                return err
+                return nil, err
         }
 
         if resp.StatusCode != http.StatusOK {
@@ -123,17 +340,18 @@ func (fm *fileManager) UploadFile(chatID int64, filePath, caption string) error
                         core.Field{"status", resp.Status},
                         core.Field{"body", string(respBody)},
                 )
-                return fmt.Errorf("upload failed with status: %s", resp.Status)
+                return nil, fmt.Errorf("upload failed with status: %s", resp.Status)
         }
-
-        fm.logger.Info("File uploaded successfully", core.Field{"chat_id", chatID}, core.Field{"file", filePath})
-        return nil
+        return respBody, nil
 }
 
 // DownloadFile скачивает файл по file_id. Сначала вызывается getFile для получения пути, затем происходит скачивание.
 func (fm *fileManager) DownloadFile(fileID string) ([]byte, error) {
+        release, _ := fm.acquire(context.Background())
+        defer release()
+
         // Шаг 1. Вызов getFile для получения file_path.
-        getFileURL := fmt.Sprintf("https://api.telegram.org/bot%s/getFile?file_id=%s", fm.token, fileID)
+        getFileURL := fmt.Sprintf("%s/bot%s/getFile?file_id=%s", fm.baseURL, fm.token, fileID)
         req, err := http.NewRequest("GET", getFileURL, nil)
         if err != nil {
                 fm.logger.Error("Failed to create getFile request", core.Field{"error", err})
@@ -150,7 +368,7 @@ func (fm *fileManager) DownloadFile(fileID string) ([]byte, error) {
         }
         defer resp.Body.Close()
 
-        respBody, err := ioutil.ReadAll(resp.Body)
+        respBody, err := core.ReadLimited(resp.Body, fm.maxResponseSize)
         if err != nil {
                 fm.logger.Error("Failed to read getFile response", core.Field{"error", err})
                 return nil, err
@@ -178,7 +396,7 @@ func (fm *fileManager) DownloadFile(fileID string) ([]byte, error) {
         }
 
         // Шаг 2. Скачивание файла по полученному пути.
-        downloadURL := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", fm.token, result.Result.FilePath)
+        downloadURL := fmt.Sprintf("%s/file/bot%s/%s", fm.baseURL, fm.token, result.Result.FilePath)
         reqDownload, err := http.NewRequest("GET", downloadURL, nil)
         if err != nil {
                 fm.logger.Error("Failed to create download request", core.Field{"error", err})
@@ -204,3 +422,168 @@ func (fm *fileManager) DownloadFile(fileID string) ([]byte, error) {
         fm.logger.Info("File downloaded successfully", core.Field{"file_id", fileID})
         return fileData, nil
 }
+
+// errPermanentDownload помечает ошибки скачивания, которые бессмысленно повторять.
+var errPermanentDownload = errors.New("permanent download error")
+
+// DownloadFileTo скачивает файл по file_id в dst. Если соединение обрывается посреди загрузки,
+// запрос повторяется (до downloadRetries раз) с заголовком Range, начиная с уже записанных байт.
+// Если сервер не поддерживает Range и отвечает 200, файл записывается в dst заново с начала.
+func (fm *fileManager) DownloadFileTo(ctx context.Context, fileID string, dst io.WriteSeeker) (int64, error) {
+        release, err := fm.acquire(ctx)
+        if err != nil {
+                return 0, err
+        }
+        defer release()
+
+        filePath, err := fm.getFilePath(ctx, fileID)
+        if err != nil {
+                return 0, err
+        }
+        downloadURL := fmt.Sprintf("%s/file/bot%s/%s", fm.baseURL, fm.token, filePath)
+
+        var written int64
+        for attempt := 0; ; attempt++ {
+                written, err = fm.downloadFrom(ctx, downloadURL, dst, written)
+                if err == nil {
+                        fm.logger.Info("File downloaded successfully", core.Field{"file_id", fileID}, core.Field{"bytes", written})
+                        return written, nil
+                }
+                if errors.Is(err, errPermanentDownload) || ctx.Err() != nil || attempt >= fm.downloadRetries {
+                        fm.logger.Error("File download failed", core.Field{"file_id", fileID}, core.Field{"bytes", written}, core.Field{"error", err})
+                        return written, err
+                }
+                fm.logger.Warn("File download interrupted, resuming",
+                        core.Field{"file_id", fileID},
+                        core.Field{"bytes", written},
+                        core.Field{"attempt", attempt + 1},
+                        core.Field{"error", err},
+                )
+                select {
+                case <-ctx.Done():
+                        return written, ctx.Err()
+                case <-time.After(fm.retryDelay * time.Duration(attempt+1)):
+                }
+        }
+}
+
+// downloadFrom запрашивает файл начиная с offset и дописывает его в dst.
+// Возвращает общее число байт файла, записанных в dst.
+func (fm *fileManager) downloadFrom(ctx context.Context, url string, dst io.WriteSeeker, offset int64) (int64, error) {
+        req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+        if err != nil {
+                return offset, fmt.Errorf("%w: %v", errPermanentDownload, err)
+        }
+        if offset > 0 {
+                req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+        }
+
+        var resp *http.Response
+        core.WithRecovery(fm.logger, func() {
+                resp, err = fm.httpClient.Do(req)
+        })
+        if err != nil {
+                return offset, err
+        }
+        defer resp.Body.Close()
+
+        switch {
+        case resp.StatusCode == http.StatusPartialContent && offset > 0:
+        case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+                // Всё содержимое уже получено.
+                return offset, nil
+        case resp.StatusCode == http.StatusOK:
+                offset = 0
+        case resp.StatusCode >= 500:
+                return offset, fmt.Errorf("download failed with status: %s", resp.Status)
+        default:
+                return offset, fmt.Errorf("%w: download failed with status: %s", errPermanentDownload, resp.Status)
+        }
+
+        if _, err := dst.Seek(offset, io.SeekStart); err != nil {
+                return offset, fmt.Errorf("%w: %v", errPermanentDownload, err)
+        }
+        n, err := io.Copy(dst, resp.Body)
+        return offset + n, err
+}
+
+// getFilePath вызывает getFile и возвращает путь к файлу на сервере Telegram.
+func (fm *fileManager) getFilePath(ctx context.Context, fileID string) (string, error) {
+        getFileURL := fmt.Sprintf("%s/bot%s/getFile?file_id=%s", fm.baseURL, fm.token, fileID)
+        req, err := http.NewRequestWithContext(ctx, "GET", getFileURL, nil)
+        if err != nil {
+                fm.logger.Error("Failed to create getFile request", core.Field{"error", err})
+                return "", err
+        }
+
+        var resp *http.Response
+        core.WithRecovery(fm.logger, func() {
+                resp, err = fm.httpClient.Do(req)
+        })
+        if err != nil {
+                fm.logger.Error("Error executing getFile request", core.Field{"error", err})
+                return "", err
+        }
+        defer resp.Body.Close()
+
+        var result struct {
+                OK          bool   `json:"ok"`
+                Result      File   `json:"result"`
+                Description string `json:"description"`
+        }
+        respBody, err := core.ReadLimited(resp.Body, fm.maxResponseSize)
+        if err != nil {
+                fm.logger.Error("Failed to read getFile response", core.Field{"error", err})
+                return "", err
+        }
+        if err := json.Unmarshal(respBody, &result); err != nil {
+                fm.logger.Error("Failed to decode getFile response", core.Field{"error", err})
+                return "", err
+        }
+        if !result.OK || result.Result.FilePath == "" {
+                fm.logger.Error("Telegram API returned error for getFile", core.Field{"description", result.Description})
+                return "", fmt.Errorf("telegram API error: %s", result.Description)
+        }
+        return result.Result.FilePath, nil
+}
+
+// DownloadChatPhoto получает чат через BotAPI.GetChat, выбирает file_id фотографии нужного размера
+// и скачивает её через DownloadFileTo. Если фотографии нет, возвращается ErrNoChatPhoto.
+func (fm *fileManager) DownloadChatPhoto(ctx context.Context, chatID core.ChatID, big bool) ([]byte, error) {
+        chat, err := fm.api.GetChat(ctx, chatID)
+        if err != nil {
+                return nil, err
+        }
+        if chat.Photo == nil {
+                return nil, ErrNoChatPhoto
+        }
+        fileID := chat.Photo.SmallFileID
+        if big {
+                fileID = chat.Photo.BigFileID
+        }
+
+        var buf writeSeekBuffer
+        if _, err := fm.DownloadFileTo(ctx, fileID, &buf); err != nil {
+                return nil, err
+        }
+        return buf.data, nil
+}
+
+// writeSeekBuffer – io.WriteSeeker в памяти, позволяющий DownloadFileTo докачивать файл без временных файлов.
+// Seek отбрасывает данные после новой позиции, так как downloadFrom всегда дописывает файл с неё до конца.
+type writeSeekBuffer struct {
+        data []byte
+}
+
+func (b *writeSeekBuffer) Write(p []byte) (int, error) {
+        b.data = append(b.data, p...)
+        return len(p), nil
+}
+
+func (b *writeSeekBuffer) Seek(offset int64, whence int) (int64, error) {
+        if whence != io.SeekStart || offset < 0 || offset > int64(len(b.data)) {
+                return 0, fmt.Errorf("unsupported seek: offset %d, whence %d", offset, whence)
+        }
+        b.data = b.data[:offset]
+        return offset, nil
+}
@@ -0,0 +1,284 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+func TestDownloadFileToResumesWithRange(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	var ranges []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/botTEST_TOKEN/getFile":
+			fmt.Fprint(w, `{"ok":true,"result":{"file_id":"f1","file_path":"documents/file.bin"}}`)
+		case "/file/botTEST_TOKEN/documents/file.bin":
+			rng := r.Header.Get("Range")
+			ranges = append(ranges, rng)
+			if rng == "" {
+				// Отдаём половину файла и обрываем соединение.
+				w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, content[:len(content)/2])
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			var start int
+			fmt.Sscanf(rng, "bytes=%d-", &start)
+			w.WriteHeader(http.StatusPartialContent)
+			fmt.Fprint(w, content[start:])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	fm := NewFileManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), ts.Client(), WithBaseURL(ts.URL)).(*fileManager)
+	fm.retryDelay = 0
+
+	dst, err := os.CreateTemp(t.TempDir(), "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	n, err := fm.DownloadFileTo(context.Background(), "f1", dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("got %d bytes, want %d", n, len(content))
+	}
+	data, _ := os.ReadFile(dst.Name())
+	if string(data) != content {
+		t.Errorf("downloaded content mismatch")
+	}
+	if len(ranges) != 2 || ranges[1] != fmt.Sprintf("bytes=%d-", len(content)/2) {
+		t.Errorf("unexpected Range headers: %q", ranges)
+	}
+}
+
+func TestMaxConcurrentTransfers(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/getFile") {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			fmt.Fprint(w, `{"ok":true,"result":{"file_id":"f1","file_path":"a.bin"}}`)
+			return
+		}
+		fmt.Fprint(w, "data")
+	}))
+	defer ts.Close()
+
+	fm := NewFileManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), ts.Client(), WithBaseURL(ts.URL), WithMaxConcurrentTransfers(2))
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := fm.DownloadFile("f1"); err != nil {
+				t.Errorf("DownloadFile: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxInFlight > 2 {
+		t.Errorf("got %d concurrent transfers, limit is 2", maxInFlight)
+	}
+}
+
+func TestUploadOnceCachesFileID(t *testing.T) {
+	var uploads int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botTEST_TOKEN/sendDocument" {
+			http.NotFound(w, r)
+			return
+		}
+		uploads++
+		if got := r.FormValue("chat_id"); got != "-1001" {
+			t.Errorf("chat_id = %q, want -1001", got)
+		}
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"document":{"file_id":"BQAD123"}}}`)
+	}))
+	defer ts.Close()
+
+	path := t.TempDir() + "/report.pdf"
+	if err := os.WriteFile(path, []byte("pdf"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := core.NewLogger(core.FatalLevel)
+	if _, err := NewFileManager("TEST_TOKEN", logger, ts.Client(), WithBaseURL(ts.URL)).UploadOnce(context.Background(), path); err != ErrNoStorageChat {
+		t.Fatalf("got %v, want ErrNoStorageChat", err)
+	}
+
+	fm := NewFileManager("TEST_TOKEN", logger, ts.Client(), WithBaseURL(ts.URL), WithStorageChat(-1001))
+	for i := 0; i < 2; i++ {
+		fileID, err := fm.UploadOnce(context.Background(), path)
+		if err != nil {
+			t.Fatalf("UploadOnce: %v", err)
+		}
+		if fileID != "BQAD123" {
+			t.Errorf("fileID = %q, want BQAD123", fileID)
+		}
+	}
+	if uploads != 1 {
+		t.Errorf("file uploaded %d times, want 1", uploads)
+	}
+}
+
+func TestUploadOnceSharesConcurrentUpload(t *testing.T) {
+	var mu sync.Mutex
+	uploads := 0
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		uploads++
+		mu.Unlock()
+		<-release
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"document":{"file_id":"BQAD123"}}}`)
+	}))
+	defer ts.Close()
+
+	path := t.TempDir() + "/report.pdf"
+	if err := os.WriteFile(path, []byte("pdf"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fm := NewFileManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), ts.Client(), WithBaseURL(ts.URL), WithStorageChat(-1001))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if fileID, err := fm.UploadOnce(context.Background(), path); err != nil || fileID != "BQAD123" {
+				t.Errorf("UploadOnce = %q, %v", fileID, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if uploads != 1 {
+		t.Errorf("file uploaded %d times, want 1", uploads)
+	}
+}
+
+func TestUploadOnceOwnerCancelDoesNotFailWaiters(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"document":{"file_id":"BQAD123"}}}`)
+	}))
+	defer ts.Close()
+
+	path := t.TempDir() + "/report.pdf"
+	if err := os.WriteFile(path, []byte("pdf"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fm := NewFileManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), ts.Client(), WithBaseURL(ts.URL), WithStorageChat(-1001))
+
+	ownerCtx, cancelOwner := context.WithCancel(context.Background())
+	ownerErr := make(chan error, 1)
+	go func() {
+		_, err := fm.UploadOnce(ownerCtx, path)
+		ownerErr <- err
+	}()
+	<-started
+	waiter := make(chan string, 1)
+	go func() {
+		fileID, err := fm.UploadOnce(context.Background(), path)
+		if err != nil {
+			t.Errorf("waiter: %v", err)
+		}
+		waiter <- fileID
+	}()
+	cancelOwner()
+	if err := <-ownerErr; err != context.Canceled {
+		t.Errorf("owner got %v, want context.Canceled", err)
+	}
+	close(release)
+	if fileID := <-waiter; fileID != "BQAD123" {
+		t.Errorf("waiter fileID = %q, want BQAD123", fileID)
+	}
+}
+
+func TestDownloadChatPhoto(t *testing.T) {
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/botTEST_TOKEN/getChat":
+			if r.URL.Query().Get("chat_id") == "42" {
+				fmt.Fprint(w, `{"ok":true,"result":{"id":42,"photo":{"small_file_id":"small","small_file_unique_id":"s1","big_file_id":"big","big_file_unique_id":"b1"}}}`)
+				return
+			}
+			fmt.Fprint(w, `{"ok":true,"result":{"id":7}}`)
+		case "/botTEST_TOKEN/getFile":
+			fileID := r.URL.Query().Get("file_id")
+			requested = append(requested, fileID)
+			fmt.Fprintf(w, `{"ok":true,"result":{"file_id":%q,"file_path":"photos/%s.jpg"}}`, fileID, fileID)
+		case "/file/botTEST_TOKEN/photos/big.jpg":
+			fmt.Fprint(w, "BIG")
+		case "/file/botTEST_TOKEN/photos/small.jpg":
+			fmt.Fprint(w, "SMALL")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	fm := NewFileManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), ts.Client(), WithBaseURL(ts.URL))
+	for big, want := range map[bool]string{true: "BIG", false: "SMALL"} {
+		data, err := fm.DownloadChatPhoto(context.Background(), core.ChatIDFromInt(42), big)
+		if err != nil {
+			t.Fatalf("DownloadChatPhoto(big=%v): %v", big, err)
+		}
+		if string(data) != want {
+			t.Errorf("DownloadChatPhoto(big=%v) = %q, want %q", big, data, want)
+		}
+	}
+	if len(requested) != 2 {
+		t.Errorf("getFile called %d times, want 2", len(requested))
+	}
+
+	if _, err := fm.DownloadChatPhoto(context.Background(), core.ChatIDFromInt(7), true); err != ErrNoChatPhoto {
+		t.Errorf("got %v, want ErrNoChatPhoto", err)
+	}
+}
+
+func TestDownloadChatPhotoUsesBotAPI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+	}))
+	defer ts.Close()
+
+	logger := core.NewLogger(core.FatalLevel)
+	api := core.NewBotClient("TEST_TOKEN", logger, ts.Client(), core.WithBaseURL(ts.URL))
+	fm := NewFileManager("TEST_TOKEN", logger, ts.Client(), WithBotAPI(api))
+	var apiErr *core.APIError
+	if _, err := fm.DownloadChatPhoto(context.Background(), core.ChatIDFromInt(42), true); !errors.As(err, &apiErr) || apiErr.Method != "getChat" {
+		t.Errorf("got %v, want getChat APIError", err)
+	}
+}
@@ -0,0 +1,163 @@
+package keyboards
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+// MaxCallbackDataSize – максимальный размер callback_data в байтах, допускаемый Telegram.
+const MaxCallbackDataSize = 64
+
+// CallbackDataSeparator разделяет части callback_data, например "order:12345:confirm".
+const CallbackDataSeparator = ':'
+
+// callbackDataEscape экранирует разделитель и саму себя внутри частей.
+const callbackDataEscape = '\\'
+
+var (
+	// ErrCallbackDataTooLong возвращается, если закодированные данные превышают MaxCallbackDataSize.
+	ErrCallbackDataTooLong = errors.New("callback data exceeds 64 bytes")
+	// ErrInvalidCallbackData возвращается DecodeCallbackData для некорректно экранированных данных.
+	ErrInvalidCallbackData = errors.New("invalid callback data")
+)
+
+// EncodeCallbackData объединяет части в строку callback_data через ':'. Символы ':' и '\'
+// внутри частей экранируются обратной косой чертой, поэтому DecodeCallbackData
+// восстанавливает части без изменений. Если результат длиннее MaxCallbackDataSize байт,
+// возвращается ошибка, оборачивающая ErrCallbackDataTooLong.
+func EncodeCallbackData(parts ...string) (string, error) {
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteByte(CallbackDataSeparator)
+		}
+		for j := 0; j < len(part); j++ {
+			if part[j] == CallbackDataSeparator || part[j] == callbackDataEscape {
+				b.WriteByte(callbackDataEscape)
+			}
+			b.WriteByte(part[j])
+		}
+	}
+	data := b.String()
+	if len(data) > MaxCallbackDataSize {
+		return "", fmt.Errorf("%w: %d bytes", ErrCallbackDataTooLong, len(data))
+	}
+	return data, nil
+}
+
+// DecodeCallbackData разбирает строку, полученную от EncodeCallbackData, обратно на части.
+func DecodeCallbackData(data string) ([]string, error) {
+	if len(data) > MaxCallbackDataSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrCallbackDataTooLong, len(data))
+	}
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case callbackDataEscape:
+			i++
+			if i == len(data) {
+				return nil, fmt.Errorf("%w: trailing escape character", ErrInvalidCallbackData)
+			}
+			b.WriteByte(data[i])
+		case CallbackDataSeparator:
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(data[i])
+		}
+	}
+	return append(parts, b.String()), nil
+}
+
+// ParseCallbackData разбирает callback_data на части, как DecodeCallbackData, но без ошибки:
+// для некорректных данных возвращается nil. Удобно в обработчиках, где данные
+// сформированы EncodeCallbackData и ошибка разбора означает чужую кнопку.
+func ParseCallbackData(data string) []string {
+	parts, err := DecodeCallbackData(data)
+	if err != nil {
+		return nil
+	}
+	return parts
+}
+
+// CallbackArgs – аргументы колбэка: части callback_data после действия.
+type CallbackArgs []string
+
+// String возвращает аргумент i или пустую строку, если его нет.
+func (a CallbackArgs) String(i int) string {
+	if i < 0 || i >= len(a) {
+		return ""
+	}
+	return a[i]
+}
+
+// Int возвращает аргумент i как целое число.
+func (a CallbackArgs) Int(i int) (int, error) {
+	if i < 0 || i >= len(a) {
+		return 0, fmt.Errorf("%w: missing argument %d", ErrInvalidCallbackData, i)
+	}
+	n, err := strconv.Atoi(a[i])
+	if err != nil {
+		return 0, fmt.Errorf("%w: argument %d: %v", ErrInvalidCallbackData, i, err)
+	}
+	return n, nil
+}
+
+// Int64 возвращает аргумент i как int64, например ID чата или пользователя.
+func (a CallbackArgs) Int64(i int) (int64, error) {
+	if i < 0 || i >= len(a) {
+		return 0, fmt.Errorf("%w: missing argument %d", ErrInvalidCallbackData, i)
+	}
+	n, err := strconv.ParseInt(a[i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: argument %d: %v", ErrInvalidCallbackData, i, err)
+	}
+	return n, nil
+}
+
+// CallbackActionHandler обрабатывает колбэк действия с уже разобранными аргументами.
+type CallbackActionHandler func(update core.Update, args CallbackArgs) error
+
+// CallbackRouter направляет колбэки вида "<действие>:<аргументы>" (см. EncodeCallbackData)
+// в обработчики по первой части данных:
+//
+//	actions := keyboards.NewCallbackRouter(router)
+//	actions.Handle("delete", func(update core.Update, args keyboards.CallbackArgs) error {
+//		id, err := args.Int64(0) // "delete:42" -> 42
+//		...
+//	})
+type CallbackRouter struct {
+	router core.Router
+}
+
+// NewCallbackRouter создаёт CallbackRouter, регистрирующий обработчики в router.
+func NewCallbackRouter(router core.Router) *CallbackRouter {
+	return &CallbackRouter{router: router}
+}
+
+// Handle регистрирует handler для действия action: он вызывается для данных, равных action
+// (без аргументов) или начинающихся с "action:". Точные обработчики router.HandleCallback
+// сохраняют приоритет.
+func (c *CallbackRouter) Handle(action string, handler CallbackActionHandler) {
+	prefix, err := EncodeCallbackData(action)
+	if err != nil {
+		prefix = action
+	}
+	wrapped := func(update core.Update) error {
+		if update.CallbackQuery == nil {
+			return nil
+		}
+		parts, err := DecodeCallbackData(update.CallbackQuery.Data)
+		if err != nil {
+			return err
+		}
+		return handler(update, CallbackArgs(parts[1:]))
+	}
+	c.router.HandleCallback(prefix, wrapped)
+	c.router.HandleCallbackPrefix(prefix+string(CallbackDataSeparator), wrapped)
+}
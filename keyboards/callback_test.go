@@ -0,0 +1,76 @@
+package keyboards
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+func TestCallbackDataRoundTrip(t *testing.T) {
+	cases := [][]string{
+		{"order", "12345", "confirm"},
+		{"note", `a:b\c`, ""},
+		{"single"},
+	}
+	for _, parts := range cases {
+		data, err := EncodeCallbackData(parts...)
+		if err != nil {
+			t.Fatalf("EncodeCallbackData(%q): %v", parts, err)
+		}
+		got, err := DecodeCallbackData(data)
+		if err != nil {
+			t.Fatalf("DecodeCallbackData(%q): %v", data, err)
+		}
+		if !reflect.DeepEqual(got, parts) {
+			t.Errorf("round trip of %q gave %q (encoded %q)", parts, got, data)
+		}
+	}
+}
+
+func TestEncodeCallbackDataTooLong(t *testing.T) {
+	if _, err := EncodeCallbackData("order", strings.Repeat("x", 59)); !errors.Is(err, ErrCallbackDataTooLong) {
+		t.Errorf("got %v, want ErrCallbackDataTooLong", err)
+	}
+	if _, err := EncodeCallbackData("order", strings.Repeat("x", 58)); err != nil {
+		t.Errorf("64-byte data rejected: %v", err)
+	}
+}
+
+func TestDecodeCallbackDataTrailingEscape(t *testing.T) {
+	if _, err := DecodeCallbackData(`order\`); !errors.Is(err, ErrInvalidCallbackData) {
+		t.Errorf("got %v, want ErrInvalidCallbackData", err)
+	}
+}
+
+func TestCallbackRouterDispatchesByAction(t *testing.T) {
+	router := core.NewRouter(core.NewLogger(core.FatalLevel))
+	actions := NewCallbackRouter(router)
+	var gotID int64
+	var gotArgs int
+	actions.Handle("delete", func(update core.Update, args CallbackArgs) error {
+		gotArgs = len(args)
+		if len(args) == 0 {
+			return nil
+		}
+		var err error
+		gotID, err = args.Int64(0)
+		return err
+	})
+
+	data, _ := EncodeCallbackData("delete", "42")
+	if err := router.Route(core.Update{CallbackQuery: &core.CallbackQuery{Data: data}}); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if gotID != 42 || gotArgs != 1 {
+		t.Errorf("got id %d with %d args, want 42 with 1", gotID, gotArgs)
+	}
+	if err := router.Route(core.Update{CallbackQuery: &core.CallbackQuery{Data: "delete"}}); err != nil || gotArgs != 0 {
+		t.Errorf("bare action: err %v, args %d", err, gotArgs)
+	}
+	if parts := ParseCallbackData(`bad\`); parts != nil {
+		t.Errorf("ParseCallbackData of invalid data = %q, want nil", parts)
+	}
+}
@@ -3,6 +3,7 @@ package keyboards
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/VVolf8/go-telegram-bot/core"
 )
@@ -26,6 +27,29 @@ func NewInlineKeyboardMarkup() *InlineKeyboardMarkup {
 	}
 }
 
+// InlinePair – текст кнопки и её callback_data для InlineFromPairs.
+type InlinePair struct {
+	Text string
+	Data string
+}
+
+// InlineFromPairs строит inline-клавиатуру из таблицы пар «текст – callback_data»:
+// каждая строка pairs становится рядом клавиатуры, пустые строки пропускаются.
+func InlineFromPairs(pairs [][]InlinePair) *InlineKeyboardMarkup {
+	markup := NewInlineKeyboardMarkup()
+	for _, row := range pairs {
+		if len(row) == 0 {
+			continue
+		}
+		buttons := make([]InlineKeyboardButton, len(row))
+		for i, pair := range row {
+			buttons[i] = InlineKeyboardButton{Text: pair.Text, CallbackData: pair.Data}
+		}
+		markup.InlineKeyboard = append(markup.InlineKeyboard, buttons)
+	}
+	return markup
+}
+
 // InlineKeyboardBuilder предоставляет функциональный подход для создания inline клавиатур.
 type InlineKeyboardBuilder struct {
 	markup *InlineKeyboardMarkup
@@ -72,3 +96,26 @@ func (ikm *InlineKeyboardMarkup) ToJSON(ctx context.Context) ([]byte, error) {
 	}
 	return json.Marshal(ikm)
 }
+
+// ValidateMarkup проверяет клавиатуру перед отправкой (реализует core.ReplyMarkup): ни один ряд
+// не пуст, у каждой кнопки задан текст и ровно одно действие (url или callback_data),
+// а callback_data не длиннее MaxCallbackDataSize байт. Клавиатура без рядов допустима:
+// так inline-клавиатура убирается через editMessageReplyMarkup.
+func (ikm InlineKeyboardMarkup) ValidateMarkup() error {
+	for i, row := range ikm.InlineKeyboard {
+		if len(row) == 0 {
+			return fmt.Errorf("%w: inline keyboard row %d is empty", core.ErrInvalidReplyMarkup, i)
+		}
+		for j, button := range row {
+			switch {
+			case button.Text == "":
+				return fmt.Errorf("%w: button %d in row %d has no text", core.ErrInvalidReplyMarkup, j, i)
+			case (button.URL == "") == (button.CallbackData == ""):
+				return fmt.Errorf("%w: button %q must have exactly one of url or callback_data", core.ErrInvalidReplyMarkup, button.Text)
+			case len(button.CallbackData) > MaxCallbackDataSize:
+				return fmt.Errorf("%w: callback_data of button %q exceeds %d bytes", core.ErrInvalidReplyMarkup, button.Text, MaxCallbackDataSize)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package keyboards
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+func TestReplyKeyboardFromStrings(t *testing.T) {
+	rk := ReplyKeyboardFromStrings([][]string{{"Yes", "No"}, {}, {"Cancel"}}, WithResize(), WithOneTime())
+	data, err := json.Marshal(rk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"keyboard":[[{"text":"Yes"},{"text":"No"}],[{"text":"Cancel"}]],"resize_keyboard":true,"one_time_keyboard":true}`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+}
+
+func TestInlineFromPairs(t *testing.T) {
+	markup := InlineFromPairs([][]InlinePair{
+		{{Text: "Like", Data: "vote:up"}, {Text: "Dislike", Data: "vote:down"}},
+	})
+	data, err := json.Marshal(markup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"inline_keyboard":[[{"text":"Like","callback_data":"vote:up"},{"text":"Dislike","callback_data":"vote:down"}]]}`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+}
+
+func TestRequestChatButtonJSON(t *testing.T) {
+	isForum := true
+	button := ReplyKeyboardButton{
+		Text:        "Pick a chat",
+		RequestChat: &KeyboardButtonRequestChat{RequestID: 1, ChatIsForum: &isForum, BotIsMember: true},
+	}
+	data, err := json.Marshal(button)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"text":"Pick a chat","request_chat":{"request_id":1,"chat_is_channel":false,"chat_is_forum":true,"bot_is_member":true}}`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+}
+
+func TestValidateMarkup(t *testing.T) {
+	valid := InlineFromPairs([][]InlinePair{{{Text: "OK", Data: "ok"}}})
+	if err := core.ValidateReplyMarkup(valid); err != nil {
+		t.Errorf("valid inline keyboard rejected: %v", err)
+	}
+	// Пустая клавиатура убирает inline-клавиатуру у сообщения.
+	if err := core.ValidateReplyMarkup(NewInlineKeyboardMarkup()); err != nil {
+		t.Errorf("empty inline keyboard rejected: %v", err)
+	}
+	if err := core.ValidateReplyMarkup(NewPaginator("items", 5).Keyboard(0, 0, nil)); err != nil {
+		t.Errorf("paginator keyboard for an empty list rejected: %v", err)
+	}
+	invalid := []interface{}{
+		&InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{}}},
+		&InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "No action"}}}},
+		&InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "Long", CallbackData: strings.Repeat("x", 65)}}}},
+		ReplyKeyboardMarkup{Keyboard: [][]ReplyKeyboardButton{{{Text: ""}}}},
+	}
+	for _, markup := range invalid {
+		if err := core.ValidateReplyMarkup(markup); !errors.Is(err, core.ErrInvalidReplyMarkup) {
+			t.Errorf("%+v: got %v, want ErrInvalidReplyMarkup", markup, err)
+		}
+	}
+}
+
+func TestResizableReplyKeyboardBuilder(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	markup := NewResizableReplyKeyboardBuilder(logger).AddRow(ReplyKeyboardButton{Text: "Menu"}).Build()
+	if !markup.ResizeKeyboard {
+		t.Error("resizable builder must enable resize_keyboard")
+	}
+	if NewResizableReplyKeyboardBuilder(logger).SetResizeKeyboard(false).Build().ResizeKeyboard {
+		t.Error("SetResizeKeyboard(false) must opt out")
+	}
+}
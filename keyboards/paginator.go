@@ -0,0 +1,115 @@
+package keyboards
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Кнопки навигации Paginator.
+const (
+	PaginatorPrevText = "◀"
+	PaginatorNextText = "▶"
+)
+
+// Paginator строит inline-клавиатуры для постраничного просмотра длинных списков.
+// Смещение текущей страницы хранится в callback_data кнопок навигации в виде
+// "<prefix>:<offset>", поэтому состояние не нужно хранить на стороне бота:
+//
+//	pager := keyboards.NewPaginator("orders", 10)
+//	router.HandleCallbackPrefix("orders:", func(update core.Update) error {
+//		offset, ok := pager.ParseOffset(update.CallbackQuery.Data)
+//		if !ok {
+//			return nil
+//		}
+//		markup := pager.Keyboard(offset, len(orders), func(i int) keyboards.InlineKeyboardButton {
+//			return keyboards.InlineKeyboardButton{Text: orders[i].Title, CallbackData: "order:" + orders[i].ID}
+//		})
+//		return api.AnswerAndEdit(update.Context(), *update.CallbackQuery, "Orders", markup)
+//	})
+type Paginator struct {
+	prefix   string
+	pageSize int
+}
+
+// NewPaginator создаёт пагинатор с префиксом callback_data prefix и pageSize элементами
+// на странице (не меньше одного).
+func NewPaginator(prefix string, pageSize int) *Paginator {
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	return &Paginator{prefix: prefix, pageSize: pageSize}
+}
+
+// PageData возвращает callback_data, открывающую страницу со смещением offset.
+func (p *Paginator) PageData(offset int) string {
+	data, err := EncodeCallbackData(p.prefix, strconv.Itoa(offset))
+	if err != nil {
+		// Префикс слишком длинный: возвращаем данные как есть, Telegram отклонит их при отправке.
+		return fmt.Sprintf("%s%c%d", p.prefix, CallbackDataSeparator, offset)
+	}
+	return data
+}
+
+// ParseOffset извлекает смещение из callback_data кнопки навигации. Возвращает false,
+// если данные не относятся к этому пагинатору.
+func (p *Paginator) ParseOffset(data string) (int, bool) {
+	parts, err := DecodeCallbackData(data)
+	if err != nil || len(parts) != 2 || parts[0] != p.prefix {
+		return 0, false
+	}
+	offset, err := strconv.Atoi(parts[1])
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+	return offset, true
+}
+
+// Keyboard строит клавиатуру страницы со смещением offset для списка из total элементов:
+// по ряду на каждый элемент страницы (кнопку строит item по индексу элемента) и ряд
+// навигации из PaginatorPrevText, номера страницы и PaginatorNextText. Кнопки перехода
+// за пределы списка не добавляются; при одной странице ряд навигации не выводится.
+// Смещение за пределами списка приводится к последней странице.
+func (p *Paginator) Keyboard(offset, total int, item func(i int) InlineKeyboardButton) *InlineKeyboardMarkup {
+	offset = p.clamp(offset, total)
+	markup := NewInlineKeyboardMarkup()
+	end := offset + p.pageSize
+	if end > total {
+		end = total
+	}
+	for i := offset; i < end; i++ {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []InlineKeyboardButton{item(i)})
+	}
+	if total <= p.pageSize {
+		return markup
+	}
+
+	var nav []InlineKeyboardButton
+	if offset > 0 {
+		prev := offset - p.pageSize
+		if prev < 0 {
+			prev = 0
+		}
+		nav = append(nav, InlineKeyboardButton{Text: PaginatorPrevText, CallbackData: p.PageData(prev)})
+	}
+	pages := (total + p.pageSize - 1) / p.pageSize
+	nav = append(nav, InlineKeyboardButton{
+		Text:         fmt.Sprintf("%d/%d", offset/p.pageSize+1, pages),
+		CallbackData: p.PageData(offset),
+	})
+	if end < total {
+		nav = append(nav, InlineKeyboardButton{Text: PaginatorNextText, CallbackData: p.PageData(end)})
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, nav)
+	return markup
+}
+
+// clamp приводит offset к началу существующей страницы.
+func (p *Paginator) clamp(offset, total int) int {
+	if offset < 0 || total <= 0 {
+		return 0
+	}
+	if offset >= total {
+		offset = total - 1
+	}
+	return offset - offset%p.pageSize
+}
@@ -0,0 +1,42 @@
+package keyboards
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestPaginatorKeyboard(t *testing.T) {
+	pager := NewPaginator("list", 10)
+	item := func(i int) InlineKeyboardButton {
+		return InlineKeyboardButton{Text: strconv.Itoa(i), CallbackData: "item:" + strconv.Itoa(i)}
+	}
+
+	first := pager.Keyboard(0, 25, item)
+	if len(first.InlineKeyboard) != 11 {
+		t.Fatalf("got %d rows, want 10 items and navigation", len(first.InlineKeyboard))
+	}
+	nav := first.InlineKeyboard[10]
+	if len(nav) != 2 || nav[0].Text != "1/3" || nav[1].Text != PaginatorNextText || nav[1].CallbackData != "list:10" {
+		t.Errorf("unexpected first page navigation: %+v", nav)
+	}
+
+	offset, ok := pager.ParseOffset(nav[1].CallbackData)
+	if !ok || offset != 10 {
+		t.Fatalf("ParseOffset(%q) = %d, %v", nav[1].CallbackData, offset, ok)
+	}
+	last := pager.Keyboard(24, 25, item)
+	if len(last.InlineKeyboard) != 6 || last.InlineKeyboard[0][0].Text != "20" {
+		t.Fatalf("unexpected last page: %+v", last.InlineKeyboard)
+	}
+	nav = last.InlineKeyboard[5]
+	if len(nav) != 2 || nav[0].CallbackData != "list:10" || nav[1].Text != "3/3" {
+		t.Errorf("unexpected last page navigation: %+v", nav)
+	}
+
+	if single := pager.Keyboard(0, 3, item); len(single.InlineKeyboard) != 3 {
+		t.Errorf("single page must not have navigation: %+v", single.InlineKeyboard)
+	}
+	if _, ok := pager.ParseOffset("other:10"); ok {
+		t.Error("foreign callback data accepted")
+	}
+}
@@ -3,6 +3,7 @@ package keyboards
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/VVolf8/go-telegram-bot/core"
 )
@@ -12,6 +13,48 @@ type ReplyKeyboardButton struct {
 	Text            string `json:"text"`
 	RequestContact  bool   `json:"request_contact,omitempty"`
 	RequestLocation bool   `json:"request_location,omitempty"`
+	// RequestUsers, RequestChat и RequestPoll превращают кнопку в запрос выбора пользователей,
+	// чата или создания опроса. Выбор приходит служебным сообщением Message.UsersShared
+	// или Message.ChatShared с тем же RequestID.
+	RequestUsers *KeyboardButtonRequestUsers `json:"request_users,omitempty"`
+	RequestChat  *KeyboardButtonRequestChat  `json:"request_chat,omitempty"`
+	RequestPoll  *KeyboardButtonPollType     `json:"request_poll,omitempty"`
+}
+
+// KeyboardButtonRequestUsers задаёт критерии пользователей, которых можно выбрать кнопкой.
+// Поля-указатели не ограничивают выбор, если равны nil.
+type KeyboardButtonRequestUsers struct {
+	// RequestID – идентификатор запроса, уникальный в пределах сообщения.
+	RequestID     int   `json:"request_id"`
+	UserIsBot     *bool `json:"user_is_bot,omitempty"`
+	UserIsPremium *bool `json:"user_is_premium,omitempty"`
+	// MaxQuantity – максимальное число выбираемых пользователей (1–10, по умолчанию 1).
+	MaxQuantity     int  `json:"max_quantity,omitempty"`
+	RequestName     bool `json:"request_name,omitempty"`
+	RequestUsername bool `json:"request_username,omitempty"`
+	RequestPhoto    bool `json:"request_photo,omitempty"`
+}
+
+// KeyboardButtonRequestChat задаёт критерии чата, который можно выбрать кнопкой.
+// Поля-указатели не ограничивают выбор, если равны nil.
+type KeyboardButtonRequestChat struct {
+	// RequestID – идентификатор запроса, уникальный в пределах сообщения.
+	RequestID       int   `json:"request_id"`
+	ChatIsChannel   bool  `json:"chat_is_channel"`
+	ChatIsForum     *bool `json:"chat_is_forum,omitempty"`
+	ChatHasUsername *bool `json:"chat_has_username,omitempty"`
+	ChatIsCreated   *bool `json:"chat_is_created,omitempty"`
+	// BotIsMember требует, чтобы бот уже состоял в выбранном чате.
+	BotIsMember     bool `json:"bot_is_member,omitempty"`
+	RequestTitle    bool `json:"request_title,omitempty"`
+	RequestUsername bool `json:"request_username,omitempty"`
+	RequestPhoto    bool `json:"request_photo,omitempty"`
+}
+
+// KeyboardButtonPollType задаёт тип опроса, создаваемого кнопкой:
+// "quiz", "regular" или пустая строка для любого типа.
+type KeyboardButtonPollType struct {
+	Type string `json:"type,omitempty"`
 }
 
 // ReplyKeyboardMarkup описывает разметку reply‑клавиатуры.
@@ -41,6 +84,12 @@ func NewReplyKeyboardBuilder(logger core.Logger) *ReplyKeyboardBuilder {
 	}
 }
 
+// NewResizableReplyKeyboardBuilder создаёт билдер reply‑клавиатуры с включённым ResizeKeyboard,
+// чтобы кнопки подстраивались под содержимое. Отключается через SetResizeKeyboard(false).
+func NewResizableReplyKeyboardBuilder(logger core.Logger) *ReplyKeyboardBuilder {
+	return NewReplyKeyboardBuilder(logger).SetResizeKeyboard(true)
+}
+
 // AddRow добавляет ряд кнопок в клавиатуру.
 func (b *ReplyKeyboardBuilder) AddRow(buttons ...ReplyKeyboardButton) *ReplyKeyboardBuilder {
 	if len(buttons) == 0 {
@@ -76,6 +125,52 @@ func (b *ReplyKeyboardBuilder) Build() *ReplyKeyboardMarkup {
 	return b.markup
 }
 
+// ReplyKeyboardOption задаёт параметры клавиатуры, создаваемой ReplyKeyboardFromStrings.
+type ReplyKeyboardOption func(*ReplyKeyboardMarkup)
+
+// WithResize подгоняет размер кнопок под их содержимое.
+func WithResize() ReplyKeyboardOption {
+	return func(rk *ReplyKeyboardMarkup) {
+		rk.ResizeKeyboard = true
+	}
+}
+
+// WithOneTime скрывает клавиатуру после нажатия кнопки.
+func WithOneTime() ReplyKeyboardOption {
+	return func(rk *ReplyKeyboardMarkup) {
+		rk.OneTimeKeyboard = true
+	}
+}
+
+// WithSelective показывает клавиатуру только упомянутым пользователям.
+func WithSelective() ReplyKeyboardOption {
+	return func(rk *ReplyKeyboardMarkup) {
+		rk.Selective = true
+	}
+}
+
+// ReplyKeyboardFromStrings строит reply‑клавиатуру из таблицы текстов кнопок:
+// каждая строка grid становится рядом клавиатуры, пустые строки пропускаются.
+func ReplyKeyboardFromStrings(grid [][]string, opts ...ReplyKeyboardOption) *ReplyKeyboardMarkup {
+	rk := &ReplyKeyboardMarkup{
+		Keyboard: make([][]ReplyKeyboardButton, 0, len(grid)),
+	}
+	for _, row := range grid {
+		if len(row) == 0 {
+			continue
+		}
+		buttons := make([]ReplyKeyboardButton, len(row))
+		for i, text := range row {
+			buttons[i] = ReplyKeyboardButton{Text: text}
+		}
+		rk.Keyboard = append(rk.Keyboard, buttons)
+	}
+	for _, opt := range opts {
+		opt(rk)
+	}
+	return rk
+}
+
 // ToJSON преобразует разметку в JSON с поддержкой контекста.
 func (rk *ReplyKeyboardMarkup) ToJSON(ctx context.Context) ([]byte, error) {
 	select {
@@ -85,3 +180,22 @@ func (rk *ReplyKeyboardMarkup) ToJSON(ctx context.Context) ([]byte, error) {
 	}
 	return json.Marshal(rk)
 }
+
+// ValidateMarkup проверяет клавиатуру перед отправкой (реализует core.ReplyMarkup):
+// в ней есть хотя бы один непустой ряд, и у каждой кнопки задан текст.
+func (rk ReplyKeyboardMarkup) ValidateMarkup() error {
+	if len(rk.Keyboard) == 0 {
+		return fmt.Errorf("%w: keyboard has no rows", core.ErrInvalidReplyMarkup)
+	}
+	for i, row := range rk.Keyboard {
+		if len(row) == 0 {
+			return fmt.Errorf("%w: keyboard row %d is empty", core.ErrInvalidReplyMarkup, i)
+		}
+		for j, button := range row {
+			if button.Text == "" {
+				return fmt.Errorf("%w: button %d in row %d has no text", core.ErrInvalidReplyMarkup, j, i)
+			}
+		}
+	}
+	return nil
+}
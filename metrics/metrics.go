@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/VVolf8/go-telegram-bot/core"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -13,48 +14,219 @@ type MetricsCollector interface {
 	IncMessageSent()
 	ObserveMessageLatency(latency float64)
 	IncErrorCount()
+	// Close снимает регистрацию всех коллекторов, чтобы метрики можно было создать заново.
+	Close() error
+}
+
+// Option задаёт необязательные параметры PrometheusMetrics.
+type Option func(*PrometheusMetrics)
+
+// WithRegisterer задаёт реестр, в котором регистрируются коллекторы.
+// По умолчанию используется prometheus.DefaultRegisterer.
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(pm *PrometheusMetrics) {
+		pm.registerer = registerer
+	}
+}
+
+// WithNamespace задаёт префикс имён метрик вместо DefaultNamespace ("bot"), например
+// "shop" даёт shop_message_sent_total. Пустая строка убирает префикс.
+// Позволяет регистрировать метрики нескольких ботов в одном реестре.
+func WithNamespace(namespace string) Option {
+	return func(pm *PrometheusMetrics) {
+		pm.namespace = namespace
+	}
+}
+
+// WithSubsystem добавляет к имени метрик подсистему после пространства имён,
+// например WithSubsystem("support") даёт bot_support_message_sent_total.
+func WithSubsystem(subsystem string) Option {
+	return func(pm *PrometheusMetrics) {
+		pm.subsystem = subsystem
+	}
+}
+
+// WithLatencySummary измеряет задержку отправки с помощью prometheus.Summary вместо
+// гистограммы с фиксированными бакетами. objectives задаёт квантили и допустимую
+// погрешность, например {0.5: 0.05, 0.99: 0.001}; при nil используются p50, p90 и p99.
+func WithLatencySummary(objectives map[float64]float64) Option {
+	return func(pm *PrometheusMetrics) {
+		if objectives == nil {
+			objectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+		}
+		pm.summaryObjectives = objectives
+		pm.nativeBucketFactor = 0
+	}
+}
+
+// WithNativeHistogram использует нативную (экспоненциальную) гистограмму Prometheus
+// с коэффициентом роста бакетов bucketFactor (например, 1.1). Классические бакеты
+// по умолчанию сохраняются для серверов без поддержки нативных гистограмм.
+func WithNativeHistogram(bucketFactor float64) Option {
+	return func(pm *PrometheusMetrics) {
+		pm.nativeBucketFactor = bucketFactor
+		pm.summaryObjectives = nil
+	}
+}
+
+// latencyMetric – гистограмма или summary для задержки отправки.
+type latencyMetric interface {
+	prometheus.Collector
+	prometheus.Observer
 }
 
 // PrometheusMetrics – реализация MetricsCollector с помощью Prometheus.
+// Кроме того, PrometheusMetrics реализует core.PollerMetrics и передаётся в core.NewPoller
+// через core.WithPollerMetrics, например
+// core.WithPollerMetrics(NewPrometheusMetrics().(*PrometheusMetrics)).
 type PrometheusMetrics struct {
-	messageSentCounter   prometheus.Counter
-	messageLatencyHist   prometheus.Histogram
-	errorCounter         prometheus.Counter
+	messageSentCounter prometheus.Counter
+	messageLatency     latencyMetric
+	errorCounter       prometheus.Counter
+	updatesReceived    prometheus.Counter
+	pollErrors         prometheus.Counter
+	lastPollTimestamp  prometheus.Gauge
+	currentOffset      prometheus.Gauge
+	registerer         prometheus.Registerer
+	// summaryObjectives и nativeBucketFactor выбирают вид метрики задержки.
+	summaryObjectives  map[float64]float64
+	nativeBucketFactor float64
+	// namespace и subsystem – префиксы имён метрик.
+	namespace string
+	subsystem string
 }
 
+// PrometheusMetrics собирает и метрики поллера.
+var _ core.PollerMetrics = (*PrometheusMetrics)(nil)
+
+// DefaultNamespace – префикс имён метрик по умолчанию.
+const DefaultNamespace = "bot"
+
 // NewPrometheusMetrics создаёт новый экземпляр PrometheusMetrics.
-func NewPrometheusMetrics() MetricsCollector {
+func NewPrometheusMetrics(opts ...Option) MetricsCollector {
 	pm := &PrometheusMetrics{
-		messageSentCounter: prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "bot_message_sent_total",
-			Help: "Общее количество отправленных сообщений ботом",
-		}),
-		messageLatencyHist: prometheus.NewHistogram(prometheus.HistogramOpts{
-			Name:    "bot_message_latency_seconds",
-			Help:    "Время отправки сообщения в секундах",
-			Buckets: prometheus.DefBuckets,
-		}),
-		errorCounter: prometheus.NewCounter(prometheus.CounterOpts{
-			Name: "bot_error_total",
-			Help: "Общее количество ошибок",
-		}),
-	}
-	prometheus.MustRegister(pm.messageSentCounter, pm.messageLatencyHist, pm.errorCounter)
+		registerer: prometheus.DefaultRegisterer,
+		namespace:  DefaultNamespace,
+	}
+	for _, opt := range opts {
+		opt(pm)
+	}
+	pm.messageSentCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: pm.namespace,
+		Subsystem: pm.subsystem,
+		Name:      "message_sent_total",
+		Help:      "Общее количество отправленных сообщений ботом",
+	})
+	pm.errorCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: pm.namespace,
+		Subsystem: pm.subsystem,
+		Name:      "error_total",
+		Help:      "Общее количество ошибок",
+	})
+	pm.updatesReceived = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: pm.namespace,
+		Subsystem: pm.subsystem,
+		Name:      "updates_received_total",
+		Help:      "Общее количество обновлений, полученных поллером",
+	})
+	pm.pollErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: pm.namespace,
+		Subsystem: pm.subsystem,
+		Name:      "poll_errors_total",
+		Help:      "Общее количество ошибок получения обновлений",
+	})
+	pm.lastPollTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: pm.namespace,
+		Subsystem: pm.subsystem,
+		Name:      "last_poll_timestamp_seconds",
+		Help:      "Unix-время последнего успешного запроса getUpdates",
+	})
+	pm.currentOffset = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: pm.namespace,
+		Subsystem: pm.subsystem,
+		Name:      "current_offset",
+		Help:      "Текущее смещение (offset) поллера",
+	})
+	pm.messageLatency = pm.newLatencyMetric()
+	pm.registerer.MustRegister(pm.collectors()...)
 	return pm
 }
 
+// newLatencyMetric создаёт метрику задержки выбранного вида.
+func (pm *PrometheusMetrics) newLatencyMetric() latencyMetric {
+	const (
+		name = "message_latency_seconds"
+		help = "Время отправки сообщения в секундах"
+	)
+	if pm.summaryObjectives != nil {
+		return prometheus.NewSummary(prometheus.SummaryOpts{
+			Namespace:  pm.namespace,
+			Subsystem:  pm.subsystem,
+			Name:       name,
+			Help:       help,
+			Objectives: pm.summaryObjectives,
+		})
+	}
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:                   pm.namespace,
+		Subsystem:                   pm.subsystem,
+		Name:                        name,
+		Help:                        help,
+		Buckets:                     prometheus.DefBuckets,
+		NativeHistogramBucketFactor: pm.nativeBucketFactor,
+	})
+}
+
+// collectors возвращает все коллекторы, принадлежащие экземпляру.
+func (pm *PrometheusMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		pm.messageSentCounter, pm.messageLatency, pm.errorCounter,
+		pm.updatesReceived, pm.pollErrors, pm.lastPollTimestamp, pm.currentOffset,
+	}
+}
+
 func (pm *PrometheusMetrics) IncMessageSent() {
 	pm.messageSentCounter.Inc()
 }
 
 func (pm *PrometheusMetrics) ObserveMessageLatency(latency float64) {
-	pm.messageLatencyHist.Observe(latency)
+	pm.messageLatency.Observe(latency)
 }
 
 func (pm *PrometheusMetrics) IncErrorCount() {
 	pm.errorCounter.Inc()
 }
 
+func (pm *PrometheusMetrics) IncUpdatesReceived(n int) {
+	pm.updatesReceived.Add(float64(n))
+}
+
+func (pm *PrometheusMetrics) IncPollErrors() {
+	pm.pollErrors.Inc()
+}
+
+func (pm *PrometheusMetrics) SetLastPollTimestamp(t time.Time) {
+	pm.lastPollTimestamp.Set(float64(t.UnixNano()) / float64(time.Second))
+}
+
+func (pm *PrometheusMetrics) SetCurrentOffset(offset int) {
+	pm.currentOffset.Set(float64(offset))
+}
+
+// Unregister снимает регистрацию коллекторов в реестре.
+// После вызова можно безопасно создать новый экземпляр PrometheusMetrics с теми же именами метрик.
+func (pm *PrometheusMetrics) Unregister() {
+	for _, c := range pm.collectors() {
+		pm.registerer.Unregister(c)
+	}
+}
+
+// Close реализует MetricsCollector и эквивалентен Unregister.
+func (pm *PrometheusMetrics) Close() error {
+	pm.Unregister()
+	return nil
+}
+
 // ExposeMetricsHandler возвращает HTTP-обработчик для экспонирования метрик.
 func ExposeMetricsHandler(addr string) error {
 	http.Handle("/metrics", promhttp.Handler())
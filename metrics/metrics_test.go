@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestCloseAllowsReRegistration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first := NewPrometheusMetrics(WithRegisterer(registry))
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("re-registering after Close panicked: %v", r)
+		}
+	}()
+	second := NewPrometheusMetrics(WithRegisterer(registry))
+	second.IncMessageSent()
+}
+
+func TestLatencySummary(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewPrometheusMetrics(WithRegisterer(registry), WithLatencySummary(map[float64]float64{0.99: 0.001}))
+	defer m.Close()
+	m.ObserveMessageLatency(0.25)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() == "bot_message_latency_seconds" {
+			if f.GetType() != dto.MetricType_SUMMARY {
+				t.Fatalf("got metric type %v, want SUMMARY", f.GetType())
+			}
+			if q := f.GetMetric()[0].GetSummary().GetQuantile(); len(q) != 1 || q[0].GetQuantile() != 0.99 {
+				t.Errorf("unexpected quantiles: %v", q)
+			}
+			return
+		}
+	}
+	t.Fatal("latency metric not registered")
+}
+
+func TestPollerMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector := NewPrometheusMetrics(WithRegisterer(registry))
+	defer collector.Close()
+	var m core.PollerMetrics = collector.(*PrometheusMetrics)
+	m.IncUpdatesReceived(3)
+	m.IncPollErrors()
+	m.SetLastPollTimestamp(time.Unix(1700000000, 0))
+	m.SetCurrentOffset(42)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]float64)
+	for _, f := range families {
+		metric := f.GetMetric()[0]
+		switch f.GetType() {
+		case dto.MetricType_COUNTER:
+			got[f.GetName()] = metric.GetCounter().GetValue()
+		case dto.MetricType_GAUGE:
+			got[f.GetName()] = metric.GetGauge().GetValue()
+		}
+	}
+	want := map[string]float64{
+		"bot_updates_received_total":      3,
+		"bot_poll_errors_total":           1,
+		"bot_last_poll_timestamp_seconds": 1700000000,
+		"bot_current_offset":              42,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("%s = %v, want %v", name, got[name], v)
+		}
+	}
+}
+
+func TestNamespaceAndSubsystemAvoidCollisions(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first := NewPrometheusMetrics(WithRegisterer(registry), WithNamespace("shop"))
+	defer first.Close()
+	second := NewPrometheusMetrics(WithRegisterer(registry), WithSubsystem("support"))
+	defer second.Close()
+	first.IncMessageSent()
+	second.IncMessageSent()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	for _, name := range []string{"shop_message_sent_total", "bot_support_message_sent_total"} {
+		if !names[name] {
+			t.Errorf("metric %s not registered; got %v", name, names)
+		}
+	}
+}
@@ -2,16 +2,18 @@ package middleware
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf16"
 
+	"github.com/VVolf8/go-telegram-bot/cache"
 	"github.com/VVolf8/go-telegram-bot/core"
 )
 
-// MiddlewareFunc определяет функцию middleware, принимающую и возвращающую HandlerFunc.
-type MiddlewareFunc func(core.HandlerFunc) core.HandlerFunc
-
 // =======================
 // SecurityMiddleware
 // =======================
@@ -25,6 +27,7 @@ func SecurityMiddleware(allowedIDs []int64, logger core.Logger) MiddlewareFunc {
 
 	return func(next core.HandlerFunc) core.HandlerFunc {
 		return func(update core.Update) error {
+			logger := core.LoggerWithCorrelation(logger, update.Context())
 			// Проверяем, что обновление содержит сообщение и его чат ID присутствует в списке allowedIDs.
 			if update.Message == nil {
 				logger.Warn("SecurityMiddleware: update has no message")
@@ -43,35 +46,26 @@ func SecurityMiddleware(allowedIDs []int64, logger core.Logger) MiddlewareFunc {
 // =======================
 // TracingMiddleware
 // =======================
-// TracingMiddleware генерирует уникальный correlation ID для каждого обновления,
-// добавляет его в контекст и логирует его. Это позволяет отслеживать цепочки запросов.
+// TracingMiddleware гарантирует наличие correlation ID в контексте обновления.
+// Обновления, полученные поллером или вебхуком, уже содержат ID, назначенный при получении, –
+// он сохраняется; иначе генерируется новый. Обновление с этим контекстом передаётся дальше,
+// поэтому последующие middleware и обработчик логируют с тем же ID
+// (см. core.LoggerWithCorrelation).
 func TracingMiddleware(logger core.Logger) MiddlewareFunc {
 	return func(next core.HandlerFunc) core.HandlerFunc {
 		return func(update core.Update) error {
-			correlationID := generateCorrelationID()
-			// Добавляем correlation ID в контекст (если понадобится передавать его дальше)
-			ctx := context.WithValue(context.Background(), "correlation_id", correlationID)
-			// Можно также добавить его в базовые поля логгера:
-			loggerWithCorr := logger.WithFields(core.Field{"correlation_id", correlationID})
-			loggerWithCorr.Debug("TracingMiddleware: generated correlation ID", core.Field{"correlation_id", correlationID})
-			// Здесь можно передать новый контекст в обработчик, если HandlerFunc поддерживает передачу контекста.
-			// В нашем случае HandlerFunc принимает только update, поэтому просто логируем.
+			ctx := update.Context()
+			correlationID := core.CorrelationIDFromContext(ctx)
+			if correlationID == "" {
+				correlationID = core.NewCorrelationID()
+				update = update.WithContext(core.ContextWithCorrelationID(ctx, correlationID))
+				logger.Debug("TracingMiddleware: generated correlation ID", core.Field{"correlation_id", correlationID})
+			}
 			return next(update)
 		}
 	}
 }
 
-// generateCorrelationID генерирует уникальную строку с использованием crypto/rand.
-func generateCorrelationID() string {
-	b := make([]byte, 16)
-	_, err := rand.Read(b)
-	if err != nil {
-		// На случай ошибки возвращаем фиксированное значение
-		return "unknown-corr-id"
-	}
-	return hex.EncodeToString(b)
-}
-
 // =======================
 // RequestLoggingMiddleware
 // =======================
@@ -80,6 +74,7 @@ func generateCorrelationID() string {
 func RequestLoggingMiddleware(logger core.Logger) MiddlewareFunc {
 	return func(next core.HandlerFunc) core.HandlerFunc {
 		return func(update core.Update) error {
+			logger := core.LoggerWithCorrelation(logger, update.Context())
 			// Логируем входящее обновление
 			logger.Info("RequestLoggingMiddleware: received update", core.Field{"update_id", update.UpdateID})
 			err := next(update)
@@ -92,3 +87,339 @@ func RequestLoggingMiddleware(logger core.Logger) MiddlewareFunc {
 		}
 	}
 }
+
+// =======================
+// ErrorReplyMiddleware
+// =======================
+// DefaultErrorReplyText – текст, отправляемый пользователю по умолчанию при сбое обработчика.
+const DefaultErrorReplyText = "Something went wrong. Please try again later."
+
+// errorReplyTimeout ограничивает время отправки сообщения об ошибке.
+const errorReplyTimeout = 5 * time.Second
+
+// ErrHandlerPanicked возвращается ErrorReplyMiddleware, если обработчик запаниковал.
+var ErrHandlerPanicked = errors.New("handler panicked")
+
+// ErrorReplyMiddleware перехватывает панику и ошибки обработчика и отправляет в исходный чат
+// нейтральное сообщение replyText (DefaultErrorReplyText, если пусто). Подробности ошибки
+// пользователю не показываются – они только логируются. Ошибка обработчика (или
+// ErrHandlerPanicked при панике) возвращается дальше по цепочке.
+func ErrorReplyMiddleware(api core.BotAPI, replyText string, logger core.Logger) MiddlewareFunc {
+	if replyText == "" {
+		replyText = DefaultErrorReplyText
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(update core.Update) (err error) {
+			logger := core.LoggerWithCorrelation(logger, update.Context())
+			panicked := true
+			core.WithRecovery(logger, func() {
+				err = next(update)
+				panicked = false
+			})
+			if panicked {
+				err = ErrHandlerPanicked
+			}
+			if err == nil {
+				return nil
+			}
+			chat := update.EffectiveChat()
+			if chat == nil {
+				logger.Warn("ErrorReplyMiddleware: update has no chat to reply to", core.Field{"update_id", update.UpdateID})
+				return err
+			}
+			// Контекст обновления может быть уже отменён, поэтому сохраняем только его значения.
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(update.Context()), errorReplyTimeout)
+			defer cancel()
+			if sendErr := api.SendMessage(ctx, core.ChatIDFromInt(chat.ID), replyText); sendErr != nil {
+				logger.Error("ErrorReplyMiddleware: failed to notify user", core.Field{"chat_id", chat.ID}, core.Field{"error", sendErr})
+			}
+			return err
+		}
+	}
+}
+
+// =======================
+// AckMiddleware
+// =======================
+// AckMode определяет, как подтверждается получение сообщения: реакцией или коротким ответом.
+type AckMode struct {
+	// Reaction – эмодзи, которое ставится на входящее сообщение.
+	Reaction string
+	// ReplyText – текст ответа на входящее сообщение (используется, если Reaction пуст).
+	ReplyText string
+}
+
+// AckReaction подтверждает получение реакцией emoji (например, "👍").
+func AckReaction(emoji string) AckMode {
+	return AckMode{Reaction: emoji}
+}
+
+// AckReply подтверждает получение ответом text на входящее сообщение.
+func AckReply(text string) AckMode {
+	return AckMode{ReplyText: text}
+}
+
+// AckMiddleware мгновенно подтверждает получение каждого входящего сообщения до вызова
+// основного обработчика. Ошибка подтверждения только логируется и не мешает обработке.
+// Обновления без Message передаются дальше без подтверждения.
+func AckMiddleware(api core.BotAPI, mode AckMode, logger core.Logger) MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(update core.Update) error {
+			if msg := update.Message; msg != nil {
+				logger := core.LoggerWithCorrelation(logger, update.Context())
+				var err error
+				switch {
+				case mode.Reaction != "":
+					err = api.SetMessageReaction(update.Context(), core.ChatIDFromInt(msg.Chat.ID), msg.MessageID, mode.Reaction)
+				case mode.ReplyText != "":
+					err = api.SendMessage(update.Context(), core.ChatIDFromInt(msg.Chat.ID), mode.ReplyText, core.WithReplyTo(msg.MessageID))
+				}
+				if err != nil {
+					logger.Warn("AckMiddleware: failed to acknowledge message", core.Field{"chat_id", msg.Chat.ID}, core.Field{"error", err})
+				}
+			}
+			return next(update)
+		}
+	}
+}
+
+// =======================
+// EnrichMiddleware
+// =======================
+// UserMeta – хранимые сведения о пользователе, которые нужны обработчикам.
+type UserMeta struct {
+	Role     string
+	Language string
+	// Data – произвольные дополнительные сведения.
+	Data map[string]interface{}
+}
+
+// userMetaKey – ключ контекста для UserMeta.
+type userMetaKey struct{}
+
+// UserMetaFromContext возвращает сведения о пользователе, добавленные EnrichMiddleware.
+func UserMetaFromContext(ctx context.Context) (UserMeta, bool) {
+	meta, ok := ctx.Value(userMetaKey{}).(UserMeta)
+	return meta, ok
+}
+
+// EnrichMiddleware загружает сведения об отправителе обновления и добавляет их в контекст
+// обновления (см. UserMetaFromContext). Сначала проверяется кэш по ключу "user_meta:<id>",
+// при промахе вызывается loader, а результат сохраняется в кэш. Если загрузить сведения
+// не удалось, ошибка логируется и обработчик вызывается без них.
+func EnrichMiddleware(c cache.Cache, loader func(userID int64) (UserMeta, error), logger core.Logger) MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(update core.Update) error {
+			user := update.EffectiveUser()
+			if user == nil {
+				return next(update)
+			}
+			logger := core.LoggerWithCorrelation(logger, update.Context())
+			userID := int64(user.ID)
+			key := fmt.Sprintf("user_meta:%d", userID)
+
+			// Любая ошибка кэша считается промахом.
+			var meta UserMeta
+			if !cache.LoadValue(c, key, &meta) {
+				var err error
+				meta, err = loader(userID)
+				if err != nil {
+					logger.Warn("EnrichMiddleware: failed to load user metadata", core.Field{"user_id", userID}, core.Field{"error", err})
+					return next(update)
+				}
+				if err := c.Set(key, meta); err != nil {
+					logger.Warn("EnrichMiddleware: failed to cache user metadata", core.Field{"user_id", userID}, core.Field{"error", err})
+				}
+			}
+			ctx := context.WithValue(update.Context(), userMetaKey{}, meta)
+			return next(update.WithContext(ctx))
+		}
+	}
+}
+
+// =======================
+// QuotaMiddleware
+// =======================
+// ErrQuotaExceeded возвращается QuotaMiddleware, если квота исчерпана.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaKeyByUser – функция ключа для QuotaMiddleware, считающая квоту по отправителю обновления.
+func QuotaKeyByUser(update core.Update) string {
+	if user := update.EffectiveUser(); user != nil {
+		return fmt.Sprintf("user:%d", user.ID)
+	}
+	return ""
+}
+
+// QuotaMiddleware ограничивает число обработанных обновлений limit штуками за окно window
+// (например, 100 генераций в сутки) для каждого ключа keyFn. В отличие от ограничения частоты,
+// квота не восстанавливается постепенно: счётчик хранится в кэше под ключом
+// "quota:<key>:<начало окна>" и обнуляется только с началом следующего окна.
+// Счётчик сохраняется со сроком жизни window (см. cache.SetWithTTL), поэтому счётчики
+// прошедших окон не накапливаются в кэшах с поддержкой TTL.
+// Сверх квоты обработчик не вызывается, а возвращается ошибка, оборачивающая ErrQuotaExceeded,
+// с моментом сброса. Обновления с пустым ключом квотой не ограничиваются.
+func QuotaMiddleware(c cache.Cache, limit int, window time.Duration, keyFn func(core.Update) string, logger core.Logger) MiddlewareFunc {
+	// Кэш не поддерживает атомарный инкремент, поэтому чтение и запись счётчика сериализуются.
+	var mu sync.Mutex
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(update core.Update) error {
+			key := keyFn(update)
+			if key == "" {
+				return next(update)
+			}
+			logger := core.LoggerWithCorrelation(logger, update.Context())
+			start := time.Now().Truncate(window)
+			counterKey := fmt.Sprintf("quota:%s:%d", key, start.Unix())
+
+			mu.Lock()
+			// Отсутствие счётчика означает ноль.
+			var used int
+			cache.LoadValue(c, counterKey, &used)
+			if used >= limit {
+				mu.Unlock()
+				resetAt := start.Add(window)
+				logger.Warn("QuotaMiddleware: quota exceeded", core.Field{"key", key}, core.Field{"limit", limit}, core.Field{"reset_at", resetAt})
+				return fmt.Errorf("%w: %d of %d used by %s, resets at %s", ErrQuotaExceeded, used, limit, key, resetAt.Format(time.RFC3339))
+			}
+			err := cache.SetWithTTL(c, counterKey, used+1, window)
+			mu.Unlock()
+			if err != nil {
+				logger.Error("QuotaMiddleware: failed to store counter", core.Field{"key", key}, core.Field{"error", err})
+				return fmt.Errorf("quota: %w", err)
+			}
+			return next(update)
+		}
+	}
+}
+
+// =======================
+// NormalizeCommandMiddleware
+// =======================
+// NormalizeCommandMiddleware приводит команду в тексте сообщения к виду, под которым она
+// зарегистрирована в роутере: убирает пробельные и невидимые символы (например, zero-width space)
+// по краям текста и внутри команды, переводит команду в нижний регистр и отрезает суффикс "@botname".
+// Так "/Start", " /start ", "/start@MyBot" и "/start" с zero-width space в начале попадают в обработчик "/start".
+// Аргументы команды не изменяются, а смещения Message.Entities пересчитываются под новый текст.
+//
+// Если botUsername пуст, отрезается любой суффикс "@...". Иначе отрезается только суффикс
+// с именем этого бота, а команды, адресованные другим ботам, остаются как есть и не совпадут
+// с зарегистрированными. Подключается до роутинга, например
+// router.Use(core.UpdateTypeMessage, middleware.NormalizeCommandMiddleware("MyBot", logger)).
+func NormalizeCommandMiddleware(botUsername string, logger core.Logger) MiddlewareFunc {
+	botUsername = strings.TrimPrefix(botUsername, "@")
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(update core.Update) error {
+			if msg := update.Message; msg != nil {
+				if n, ok := normalizeCommand(msg.Text, botUsername); ok && n.text != msg.Text {
+					core.LoggerWithCorrelation(logger, update.Context()).Debug("NormalizeCommandMiddleware: command normalized",
+						core.Field{"original", msg.Text},
+						core.Field{"text", n.text},
+					)
+					// Сообщение копируется, чтобы не менять его у других получателей обновления.
+					normalized := *msg
+					normalized.Text = n.text
+					normalized.Entities = n.remapEntities(msg.Entities)
+					update.Message = &normalized
+				}
+			}
+			return next(update)
+		}
+	}
+}
+
+// commandNormalization – результат normalizeCommand. Длины измеряются в единицах UTF-16,
+// как смещения MessageEntity.
+type commandNormalization struct {
+	text string
+	// removed – длина отброшенного начала текста (пробелы и невидимые символы).
+	removed int
+	// oldCommand и newCommand – длина команды до и после нормализации.
+	oldCommand int
+	newCommand int
+}
+
+// normalizeCommand нормализует команду в начале text; ok равен false, если текст не команда.
+func normalizeCommand(text, botUsername string) (n commandNormalization, ok bool) {
+	trimmed := strings.TrimLeftFunc(text, isSpaceOrInvisible)
+	n.removed = utf16Len(text[:len(text)-len(trimmed)])
+	trimmed = strings.TrimRightFunc(trimmed, isSpaceOrInvisible)
+	if !strings.HasPrefix(trimmed, "/") {
+		return commandNormalization{}, false
+	}
+	end := strings.IndexFunc(trimmed, unicode.IsSpace)
+	if end < 0 {
+		end = len(trimmed)
+	}
+	command := strings.ToLower(strings.Map(func(r rune) rune {
+		if isInvisible(r) {
+			return -1
+		}
+		return r
+	}, trimmed[:end]))
+	if at := strings.IndexByte(command, '@'); at >= 0 {
+		if botUsername == "" || strings.EqualFold(command[at+1:], botUsername) {
+			command = command[:at]
+		}
+	}
+	n.text = command + trimmed[end:]
+	n.oldCommand = utf16Len(trimmed[:end])
+	n.newCommand = utf16Len(command)
+	return n, true
+}
+
+// remapEntities пересчитывает смещения сущностей исходного текста под нормализованный.
+// Сущности, целиком попавшие в отброшенную часть текста, удаляются.
+func (n commandNormalization) remapEntities(entities []core.MessageEntity) []core.MessageEntity {
+	if entities == nil {
+		return nil
+	}
+	remapped := make([]core.MessageEntity, 0, len(entities))
+	for _, e := range entities {
+		start, end := n.remap(e.Offset), n.remap(e.Offset+e.Length)
+		if end <= start {
+			continue
+		}
+		e.Offset, e.Length = start, end-start
+		remapped = append(remapped, e)
+	}
+	return remapped
+}
+
+// remap переводит позицию в исходном тексте в позицию в нормализованном. Позиции внутри
+// команды прижимаются к её новой длине, позиции после команды сдвигаются на разницу длин.
+func (n commandNormalization) remap(pos int) int {
+	pos -= n.removed
+	switch {
+	case pos <= 0:
+		return 0
+	case pos <= n.oldCommand:
+		if pos > n.newCommand {
+			return n.newCommand
+		}
+		return pos
+	}
+	pos += n.newCommand - n.oldCommand
+	if total := utf16Len(n.text); pos > total {
+		return total
+	}
+	return pos
+}
+
+// utf16Len возвращает длину s в единицах UTF-16.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// isInvisible сообщает, является ли r невидимым символом форматирования (zero-width space, BOM и т.п.).
+func isInvisible(r rune) bool {
+	return unicode.Is(unicode.Cf, r)
+}
+
+func isSpaceOrInvisible(r rune) bool {
+	return unicode.IsSpace(r) || isInvisible(r)
+}
@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VVolf8/go-telegram-bot/cache"
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+func TestNormalizeCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		botUsername string
+		want        string
+		ok          bool
+	}{
+		{"uppercase", "/Start", "MyBot", "/start", true},
+		{"surrounding spaces", "  /start \n", "MyBot", "/start", true},
+		{"own bot suffix", "/start@MyBot", "MyBot", "/start", true},
+		{"own bot suffix any case", "/START@mybot", "MyBot", "/start", true},
+		{"other bot suffix", "/start@OtherBot", "MyBot", "/start@otherbot", true},
+		{"empty bot username strips any suffix", "/start@OtherBot", "", "/start", true},
+		{"leading zero-width space", "\u200b/start", "MyBot", "/start", true},
+		{"zero-width space inside command", "/sta\u200brt", "MyBot", "/start", true},
+		{"arguments keep case", "/Add Milk 2", "MyBot", "/add Milk 2", true},
+		{"not a command", "hello /start", "MyBot", "", false},
+		{"empty", "", "MyBot", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ok := normalizeCommand(tt.text, tt.botUsername)
+			if ok != tt.ok || n.text != tt.want {
+				t.Errorf("normalizeCommand(%q) = %q, %v; want %q, %v", tt.text, n.text, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNormalizeCommandRemapsEntities(t *testing.T) {
+	// " \u200b/Start@MyBot @alice": пробел и zero-width space занимают 2 единицы UTF-16,
+	// команда – 12, упоминание начинается с позиции 15.
+	n, ok := normalizeCommand(" \u200b/Start@MyBot @alice", "MyBot")
+	if !ok {
+		t.Fatal("command not recognized")
+	}
+	got := n.remapEntities([]core.MessageEntity{
+		{Type: core.MessageEntityBotCommand, Offset: 2, Length: 12},
+		{Type: "mention", Offset: 15, Length: 6},
+	})
+	want := []core.MessageEntity{
+		{Type: core.MessageEntityBotCommand, Offset: 0, Length: 6},
+		{Type: "mention", Offset: 7, Length: 6},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if mention := got[1]; mention.Text(n.text) != "@alice" {
+		t.Errorf("mention entity points at %q", mention.Text(n.text))
+	}
+}
+
+func TestNormalizeCommandMiddlewareRoutes(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	router := core.NewRouter(logger)
+	router.Use(core.UpdateTypeMessage, NormalizeCommandMiddleware("@MyBot", logger))
+	var handled []string
+	router.HandleCommand("/start", func(update core.Update) error {
+		handled = append(handled, update.Message.Text)
+		return nil
+	})
+
+	original := &core.Message{Text: "\u200b/Start@MyBot now"}
+	for _, msg := range []*core.Message{original, {Text: "/start@OtherBot"}} {
+		if err := router.Route(core.Update{Message: msg}); err != nil {
+			t.Fatalf("Route: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(handled, []string{"/start now"}) {
+		t.Errorf("handled %q, want [\"/start now\"]", handled)
+	}
+	if original.Text != "\u200b/Start@MyBot now" {
+		t.Errorf("original message modified: %q", original.Text)
+	}
+}
+
+// ttlCache записывает срок жизни, с которым сохраняются значения.
+type ttlCache struct {
+	*cache.MemoryCache
+	ttls []time.Duration
+}
+
+func (c *ttlCache) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	c.ttls = append(c.ttls, ttl)
+	return c.MemoryCache.SetWithTTL(key, value, ttl)
+}
+
+func TestQuotaMiddlewareStoresCounterWithTTL(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	c := &ttlCache{MemoryCache: cache.NewMemoryCache(logger)}
+	handler := QuotaMiddleware(c, 2, time.Hour, func(core.Update) string { return "user:1" }, logger)(func(core.Update) error {
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := handler(core.Update{}); err != nil {
+			t.Fatalf("update %d: %v", i, err)
+		}
+	}
+	if err := handler(core.Update{}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("got %v, want ErrQuotaExceeded", err)
+	}
+	if !reflect.DeepEqual(c.ttls, []time.Duration{time.Hour, time.Hour}) {
+		t.Errorf("counter stored with TTLs %v, want the window twice", c.ttls)
+	}
+}
+
+// recordingAPI записывает вызовы SendMessage и SetMessageReaction; остальные методы не реализованы.
+type recordingAPI struct {
+	core.BotAPI
+	err   error
+	calls []apiCall
+}
+
+type apiCall struct {
+	method    string
+	chatID    core.ChatID
+	text      string
+	messageID int
+	opts      core.SendOptions
+}
+
+func (a *recordingAPI) SendMessage(ctx context.Context, chatID core.ChatID, text string, opts ...core.SendOption) error {
+	call := apiCall{method: "sendMessage", chatID: chatID, text: text}
+	for _, opt := range opts {
+		opt(&call.opts)
+	}
+	a.calls = append(a.calls, call)
+	return a.err
+}
+
+func (a *recordingAPI) SetMessageReaction(ctx context.Context, chatID core.ChatID, messageID int, emoji string) error {
+	a.calls = append(a.calls, apiCall{method: "setMessageReaction", chatID: chatID, text: emoji, messageID: messageID})
+	return a.err
+}
+
+func TestErrorReplyMiddleware(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	handlerErr := errors.New("db down")
+	chatUpdate := core.Update{Message: &core.Message{MessageID: 5, Chat: core.Chat{ID: 42}}}
+	tests := []struct {
+		name    string
+		update  core.Update
+		handler core.HandlerFunc
+		wantErr error
+		replies int
+	}{
+		{"handler error", chatUpdate, func(core.Update) error { return handlerErr }, handlerErr, 1},
+		{"panic", chatUpdate, func(core.Update) error { panic("boom") }, ErrHandlerPanicked, 1},
+		{"success", chatUpdate, func(core.Update) error { return nil }, nil, 0},
+		{"no chat", core.Update{UpdateID: 1}, func(core.Update) error { return handlerErr }, handlerErr, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &recordingAPI{}
+			err := ErrorReplyMiddleware(api, "", logger)(tt.handler)(tt.update)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if len(api.calls) != tt.replies {
+				t.Fatalf("%d messages sent, want %d", len(api.calls), tt.replies)
+			}
+			if tt.replies > 0 {
+				call := api.calls[0]
+				if call.chatID != core.ChatIDFromInt(42) || call.text != DefaultErrorReplyText {
+					t.Errorf("sent %q to %v, want %q to 42", call.text, call.chatID, DefaultErrorReplyText)
+				}
+			}
+		})
+	}
+}
+
+func TestAckMiddleware(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	update := core.Update{Message: &core.Message{MessageID: 7, Chat: core.Chat{ID: 42}}}
+	tests := []struct {
+		name   string
+		mode   AckMode
+		ackErr error
+		want   apiCall
+	}{
+		{"reaction", AckReaction("👍"), nil, apiCall{method: "setMessageReaction", chatID: core.ChatIDFromInt(42), text: "👍", messageID: 7}},
+		{"reply", AckReply("got it"), nil, apiCall{method: "sendMessage", chatID: core.ChatIDFromInt(42), text: "got it", opts: core.SendOptions{ReplyToMessageID: 7}}},
+		{"ack failure", AckReaction("👍"), errors.New("forbidden"), apiCall{method: "setMessageReaction", chatID: core.ChatIDFromInt(42), text: "👍", messageID: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &recordingAPI{err: tt.ackErr}
+			called := false
+			err := AckMiddleware(api, tt.mode, logger)(func(core.Update) error {
+				called = true
+				return nil
+			})(update)
+			if err != nil || !called {
+				t.Errorf("next called=%v, err=%v; want called and nil", called, err)
+			}
+			if len(api.calls) != 1 || !reflect.DeepEqual(api.calls[0], tt.want) {
+				t.Errorf("calls = %+v, want [%+v]", api.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrichMiddleware(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	update := core.Update{Message: &core.Message{From: &core.User{ID: 9}, Chat: core.Chat{ID: 9}}}
+	c := cache.NewMemoryCache(logger)
+	var loads int
+	var loadErr error
+	loader := func(userID int64) (UserMeta, error) {
+		loads++
+		if loadErr != nil {
+			return UserMeta{}, loadErr
+		}
+		return UserMeta{Role: "admin", Language: "ru"}, nil
+	}
+	var got []UserMeta
+	var gotOK []bool
+	handler := EnrichMiddleware(c, loader, logger)(func(update core.Update) error {
+		meta, ok := UserMetaFromContext(update.Context())
+		got, gotOK = append(got, meta), append(gotOK, ok)
+		return nil
+	})
+
+	// Промах, затем попадание в кэш: loader вызывается один раз.
+	for i := 0; i < 2; i++ {
+		if err := handler(update); err != nil {
+			t.Fatalf("handler: %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loader called %d times, want 1", loads)
+	}
+	want := UserMeta{Role: "admin", Language: "ru"}
+	for i := range got {
+		if !gotOK[i] || !reflect.DeepEqual(got[i], want) {
+			t.Errorf("call %d: meta = %+v, %v; want %+v, true", i, got[i], gotOK[i], want)
+		}
+	}
+	var cached UserMeta
+	if !cache.LoadValue(c, "user_meta:9", &cached) || !reflect.DeepEqual(cached, want) {
+		t.Errorf("cached meta = %+v, want %+v", cached, want)
+	}
+
+	// Ошибка loader: обработчик вызывается без сведений.
+	if err := c.Delete("user_meta:9"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	loadErr = errors.New("db down")
+	got, gotOK = nil, nil
+	if err := handler(update); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if len(gotOK) != 1 || gotOK[0] {
+		t.Errorf("handler calls = %v, want one call without meta", gotOK)
+	}
+}
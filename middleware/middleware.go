@@ -1,13 +1,18 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/VVolf8/go-telegram-bot/core"
 )
 
 // MiddlewareFunc определяет функцию middleware, которая принимает и возвращает HandlerFunc.
-type MiddlewareFunc func(core.HandlerFunc) core.HandlerFunc
+// Тип совпадает с core.Middleware, поэтому middleware из этого пакета можно
+// регистрировать в роутере через Router.Use.
+type MiddlewareFunc = core.Middleware
 
 // ComposeMiddleware применяет цепочку middleware к базовому обработчику.
 func ComposeMiddleware(handler core.HandlerFunc, mws ...MiddlewareFunc) core.HandlerFunc {
@@ -22,6 +27,7 @@ func ComposeMiddleware(handler core.HandlerFunc, mws ...MiddlewareFunc) core.Han
 func LoggingMiddleware(logger core.Logger) MiddlewareFunc {
 	return func(next core.HandlerFunc) core.HandlerFunc {
 		return func(update core.Update) error {
+			logger := core.LoggerWithCorrelation(logger, update.Context())
 			logger.Info("Middleware: Received update", core.Field{"update_id", update.UpdateID})
 			err := next(update)
 			if err != nil {
@@ -37,6 +43,7 @@ func LoggingMiddleware(logger core.Logger) MiddlewareFunc {
 func AuthMiddleware(logger core.Logger) MiddlewareFunc {
 	return func(next core.HandlerFunc) core.HandlerFunc {
 		return func(update core.Update) error {
+			logger := core.LoggerWithCorrelation(logger, update.Context())
 			// Пример: пропускаем все обновления. В будущем можно добавить реальные проверки.
 			logger.Debug("Middleware: Auth check passed", core.Field{"update_id", update.UpdateID})
 			return next(update)
@@ -48,6 +55,7 @@ func AuthMiddleware(logger core.Logger) MiddlewareFunc {
 func TimingMiddleware(logger core.Logger) MiddlewareFunc {
 	return func(next core.HandlerFunc) core.HandlerFunc {
 		return func(update core.Update) error {
+			logger := core.LoggerWithCorrelation(logger, update.Context())
 			start := time.Now()
 			err := next(update)
 			duration := time.Since(start)
@@ -61,10 +69,45 @@ func TimingMiddleware(logger core.Logger) MiddlewareFunc {
 func RecoveryMiddleware(logger core.Logger) MiddlewareFunc {
 	return func(next core.HandlerFunc) core.HandlerFunc {
 		return func(update core.Update) (err error) {
-			core.WithRecovery(logger, func() {
+			core.WithRecovery(core.LoggerWithCorrelation(logger, update.Context()), func() {
 				err = next(update)
 			})
 			return err
 		}
 	}
 }
+
+// ErrHandlerTimeout возвращается TimeoutMiddleware, если обработчик не уложился в отведённое время.
+var ErrHandlerTimeout = errors.New("handler timed out")
+
+// TimeoutMiddleware ограничивает время выполнения обработчика значением d.
+// Обработчик получает обновление с контекстом, отменяемым по истечении d (update.Context()),
+// и должен использовать его в своих запросах, чтобы работа действительно прерывалась.
+// Если обработчик не завершился вовремя, middleware не ждёт его и возвращает ошибку,
+// оборачивающую ErrHandlerTimeout; паника в обработчике перехватывается и логируется.
+func TimeoutMiddleware(d time.Duration, logger core.Logger) MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(update core.Update) error {
+			logger := core.LoggerWithCorrelation(logger, update.Context())
+			ctx, cancel := context.WithTimeout(update.Context(), d)
+			defer cancel()
+
+			result := make(chan error, 1)
+			go func() {
+				var err error
+				core.WithRecovery(logger, func() {
+					err = next(update.WithContext(ctx))
+				})
+				result <- err
+			}()
+
+			select {
+			case err := <-result:
+				return err
+			case <-ctx.Done():
+				logger.Error("Middleware: handler timed out", core.Field{"update_id", update.UpdateID}, core.Field{"timeout", d.String()})
+				return fmt.Errorf("%w after %s: %v", ErrHandlerTimeout, d, ctx.Err())
+			}
+		}
+	}
+}
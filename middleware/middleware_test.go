@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+func TestTimeoutMiddlewareCancelsSlowHandler(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	handlerCtxErr := make(chan error, 1)
+	handler := TimeoutMiddleware(20*time.Millisecond, logger)(func(update core.Update) error {
+		<-update.Context().Done()
+		handlerCtxErr <- update.Context().Err()
+		return nil
+	})
+
+	err := handler(core.Update{UpdateID: 1})
+	if !errors.Is(err, ErrHandlerTimeout) {
+		t.Fatalf("got %v, want ErrHandlerTimeout", err)
+	}
+	select {
+	case ctxErr := <-handlerCtxErr:
+		if ctxErr != context.DeadlineExceeded {
+			t.Errorf("handler context error = %v, want context.DeadlineExceeded", ctxErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+}
+
+func TestTimeoutMiddlewarePassesResult(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	want := errors.New("handler failed")
+	handler := TimeoutMiddleware(time.Second, logger)(func(update core.Update) error {
+		return want
+	})
+	if err := handler(core.Update{}); err != want {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+func TestTimeoutMiddlewareRecoversPanic(t *testing.T) {
+	logger := core.NewLogger(core.FatalLevel)
+	handler := TimeoutMiddleware(time.Second, logger)(func(update core.Update) error {
+		panic("boom")
+	})
+	done := make(chan error, 1)
+	go func() { done <- handler(core.Update{}) }()
+	select {
+	case err := <-done:
+		if errors.Is(err, ErrHandlerTimeout) {
+			t.Errorf("panicking handler reported as timeout: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("middleware did not return after a handler panic")
+	}
+}
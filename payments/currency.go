@@ -0,0 +1,68 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Commonly used currency codes. Any ISO 4217 code supported by Telegram can be used in Invoice.Currency.
+const (
+	CurrencyUSD = "USD"
+	CurrencyEUR = "EUR"
+	CurrencyGBP = "GBP"
+	CurrencyRUB = "RUB"
+	CurrencyUAH = "UAH"
+	CurrencyKZT = "KZT"
+	CurrencyINR = "INR"
+	CurrencyJPY = "JPY"
+	CurrencyKRW = "KRW"
+	CurrencyKWD = "KWD"
+	// CurrencyStars is Telegram Stars, used for payments in digital goods.
+	CurrencyStars = "XTR"
+)
+
+// ErrUnknownCurrency is returned when the number of minor units of a currency is not known.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// minorUnitExceptions lists currencies whose minor unit exponent differs from the usual 2.
+var minorUnitExceptions = map[string]int{
+	"BHD": 3, "JOD": 3, "KWD": 3, "OMR": 3, "TND": 3,
+	"CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "PYG": 0, "UGX": 0, "VND": 0, "XTR": 0,
+}
+
+// twoDecimalCurrencies lists known currencies that use two decimal places.
+var twoDecimalCurrencies = strings.Fields(`
+	AED AFN ALL AMD ARS AUD AZN BAM BDT BGN BND BOB BRL BYN CAD CHF CNY COP CRC CZK
+	DKK DOP DZD EGP ETB EUR GBP GEL GHS GTQ HKD HNL HUF IDR ILS INR JMD KES KGS KZT
+	LBP LKR MAD MDL MMK MNT MOP MUR MVR MXN MYR MZN NGN NIO NOK NPR NZD PAB PEN PHP
+	PKR PLN QAR RON RSD RUB SAR SEK SGD SYP THB TJS TRY TTD TWD TZS UAH USD UYU UZS
+	YER ZAR`)
+
+// MinorUnits returns the number of decimal places of the currency's smallest unit
+// (2 for USD, 0 for JPY, 3 for KWD), or -1 if the currency is unknown.
+// Price.Amount must be expressed in these smallest units.
+func MinorUnits(code string) int {
+	code = strings.ToUpper(code)
+	if exp, ok := minorUnitExceptions[code]; ok {
+		return exp
+	}
+	for _, c := range twoDecimalCurrencies {
+		if c == code {
+			return 2
+		}
+	}
+	return -1
+}
+
+// ToMinorUnits converts an amount in major units (e.g. 9.99 USD) into the integer amount
+// in the smallest units expected by Price.Amount (999). The result is rounded to the nearest unit.
+// Unknown currencies yield ErrUnknownCurrency instead of guessing the exponent.
+func ToMinorUnits(code string, major float64) (int, error) {
+	exp := MinorUnits(code)
+	if exp < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+	return int(math.Round(major * math.Pow10(exp))), nil
+}
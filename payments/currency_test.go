@@ -0,0 +1,32 @@
+package payments
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToMinorUnits(t *testing.T) {
+	cases := []struct {
+		code  string
+		major float64
+		want  int
+	}{
+		{CurrencyUSD, 9.99, 999},
+		{CurrencyJPY, 500, 500},
+		{CurrencyKWD, 1.234, 1234},
+		{"eur", 0.1, 10},
+		{CurrencyStars, 50, 50},
+	}
+	for _, c := range cases {
+		got, err := ToMinorUnits(c.code, c.major)
+		if err != nil || got != c.want {
+			t.Errorf("ToMinorUnits(%q, %v) = %d, %v; want %d", c.code, c.major, got, err, c.want)
+		}
+	}
+	if _, err := ToMinorUnits("ZZZ", 1); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("expected ErrUnknownCurrency, got %v", err)
+	}
+	if MinorUnits("ZZZ") != -1 {
+		t.Error("MinorUnits of unknown currency must be -1")
+	}
+}
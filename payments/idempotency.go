@@ -0,0 +1,64 @@
+package payments
+
+import (
+	"sync"
+	"time"
+)
+
+// idempotencyEntry is the state of one operation identified by an idempotency key.
+type idempotencyEntry struct {
+	done     chan struct{}
+	err      error
+	finished time.Time
+}
+
+// idempotencyStore remembers successful operations for a limited window.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]*idempotencyEntry
+	now     func() time.Time
+}
+
+func newIdempotencyStore(window time.Duration) *idempotencyStore {
+	return &idempotencyStore{
+		window:  window,
+		entries: make(map[string]*idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// acquire returns the entry for key. owner is true if the caller must perform the operation
+// and then call complete; otherwise the caller should wait on entry.done and reuse entry.err.
+func (s *idempotencyStore) acquire(key string) (entry *idempotencyEntry, owner bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpired()
+	if entry, ok := s.entries[key]; ok {
+		return entry, false
+	}
+	entry = &idempotencyEntry{done: make(chan struct{})}
+	s.entries[key] = entry
+	return entry, true
+}
+
+// complete marks the operation as finished. Failed operations are forgotten so they can be retried.
+func (s *idempotencyStore) complete(key string, entry *idempotencyEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.finished = s.now()
+	if entry.err != nil {
+		delete(s.entries, key)
+	}
+	close(entry.done)
+}
+
+// evictExpired drops finished entries older than the window. Must be called with mu held.
+func (s *idempotencyStore) evictExpired() {
+	now := s.now()
+	for key, entry := range s.entries {
+		if !entry.finished.IsZero() && now.Sub(entry.finished) > s.window {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"time"
 
@@ -31,12 +31,20 @@ type Invoice struct {
 	// Additional optional fields can be added if needed.
 }
 
+// ShippingOption represents one shipping option offered in response to a shipping query.
+type ShippingOption struct {
+	ID     string  `json:"id"`
+	Title  string  `json:"title"`
+	Prices []Price `json:"prices"`
+}
+
 // PaymentService defines the interface for payment-related methods.
 type PaymentService interface {
 	// SendInvoice sends an invoice to a chat.
 	SendInvoice(ctx context.Context, invoice Invoice) error
 	// AnswerShippingQuery responds to a shipping query.
-	AnswerShippingQuery(ctx context.Context, shippingQueryID string, ok bool, errorMessage string) error
+	// When ok is true, the available shipping options should be passed in options.
+	AnswerShippingQuery(ctx context.Context, shippingQueryID string, ok bool, errorMessage string, options ...ShippingOption) error
 	// AnswerPreCheckoutQuery responds to a pre-checkout query.
 	AnswerPreCheckoutQuery(ctx context.Context, preCheckoutQueryID string, ok bool, errorMessage string) error
 	// HandleSuccessfulPayment processes a successful payment update.
@@ -53,23 +61,73 @@ type paymentService struct {
 	apiURL     string
 	httpClient *http.Client
 	logger     core.Logger
+	// maxResponseSize caps the size of API response bodies that are read.
+	maxResponseSize int64
+	// idempotency deduplicates repeated operations; nil when disabled.
+	idempotency *idempotencyStore
+}
+
+// Option configures optional PaymentService parameters.
+type Option func(*paymentService)
+
+// WithMaxResponseSize sets the maximum API response body size in bytes
+// (core.DefaultMaxResponseSize by default). A value <= 0 disables the limit.
+func WithMaxResponseSize(n int64) Option {
+	return func(ps *paymentService) {
+		ps.maxResponseSize = n
+	}
+}
+
+// WithIdempotencyWindow makes SendInvoice and ProcessRefund idempotent for the given window:
+// a repeated SendInvoice with the same chat ID and payload (or ProcessRefund with the same payment ID)
+// returns the result of the earlier successful call instead of performing the operation again.
+// Concurrent duplicates wait for the in-flight call or until their context is done. Failed calls are not remembered, so they can be retried.
+func WithIdempotencyWindow(window time.Duration) Option {
+	return func(ps *paymentService) {
+		if window > 0 {
+			ps.idempotency = newIdempotencyStore(window)
+		}
+	}
+}
+
+// WithRateLimiter routes requests through a rate limiter shared with other clients of the same
+// bot token (for example, core.NewBotClient with core.WithRateLimiter), so invoices and messages
+// share the token's request budget and all of them back off after a 429 response.
+func WithRateLimiter(limiter *core.RateLimiter) Option {
+	return func(ps *paymentService) {
+		ps.httpClient = limiter.WrapClient(ps.httpClient)
+	}
 }
 
 // NewPaymentService creates a new instance of PaymentService.
-func NewPaymentService(token string, logger core.Logger, httpClient *http.Client) PaymentService {
+func NewPaymentService(token string, logger core.Logger, httpClient *http.Client, opts ...Option) PaymentService {
 	if httpClient == nil {
 		httpClient = &http.Client{}
 	}
-	return &paymentService{
-		token:      token,
-		apiURL:     fmt.Sprintf("https://api.telegram.org/bot%s", token),
-		httpClient: httpClient,
-		logger:     logger,
+	ps := &paymentService{
+		token:           token,
+		apiURL:          fmt.Sprintf("https://api.telegram.org/bot%s", token),
+		httpClient:      httpClient,
+		logger:          logger,
+		maxResponseSize: core.DefaultMaxResponseSize,
+	}
+	for _, opt := range opts {
+		opt(ps)
 	}
+	return ps
 }
 
 // SendInvoice sends an invoice via Telegram.
+// With WithIdempotencyWindow, a repeated invoice with the same ChatID and Payload is not sent twice.
 func (ps *paymentService) SendInvoice(ctx context.Context, invoice Invoice) error {
+	key := fmt.Sprintf("invoice:%d:%s", invoice.ChatID, invoice.Payload)
+	return ps.idempotent(ctx, key, func() error {
+		return ps.sendInvoice(ctx, invoice)
+	})
+}
+
+// sendInvoice performs the sendInvoice request.
+func (ps *paymentService) sendInvoice(ctx context.Context, invoice Invoice) error {
 	endpoint := fmt.Sprintf("%s/sendInvoice", ps.apiURL)
 	payloadBytes, err := json.Marshal(invoice)
 	if err != nil {
@@ -94,7 +152,7 @@ func (ps *paymentService) SendInvoice(ctx context.Context, invoice Invoice) erro
 	}
 	defer resp.Body.Close()
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	bodyBytes, err := core.ReadLimited(resp.Body, ps.maxResponseSize)
 	if err != nil {
 		ps.logger.Error("Failed to read SendInvoice response", core.Field{"error", err})
 		return err
@@ -111,14 +169,26 @@ func (ps *paymentService) SendInvoice(ctx context.Context, invoice Invoice) erro
 	return nil
 }
 
+// ErrShippingOptionsRequired is returned by AnswerShippingQuery when ok is true but no
+// shipping options are given; Telegram would reject such an answer.
+var ErrShippingOptionsRequired = errors.New("answerShippingQuery: shipping options required when ok is true")
+
 // AnswerShippingQuery responds to a shipping query.
-func (ps *paymentService) AnswerShippingQuery(ctx context.Context, shippingQueryID string, ok bool, errorMessage string) error {
+// Telegram requires shipping options for a positive answer and an error message for a negative one.
+// A positive answer without options fails with ErrShippingOptionsRequired before any request is made.
+func (ps *paymentService) AnswerShippingQuery(ctx context.Context, shippingQueryID string, ok bool, errorMessage string, options ...ShippingOption) error {
+	if ok && len(options) == 0 {
+		ps.logger.Error("AnswerShippingQuery called with ok=true but without shipping options", core.Field{"shipping_query_id", shippingQueryID})
+		return ErrShippingOptionsRequired
+	}
 	endpoint := fmt.Sprintf("%s/answerShippingQuery", ps.apiURL)
 	payload := map[string]interface{}{
 		"shipping_query_id": shippingQueryID,
 		"ok":                ok,
 	}
-	if !ok {
+	if ok {
+		payload["shipping_options"] = options
+	} else {
 		payload["error_message"] = errorMessage
 	}
 	payloadBytes, err := json.Marshal(payload)
@@ -144,7 +214,7 @@ func (ps *paymentService) AnswerShippingQuery(ctx context.Context, shippingQuery
 	}
 	defer resp.Body.Close()
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	bodyBytes, err := core.ReadLimited(resp.Body, ps.maxResponseSize)
 	if err != nil {
 		ps.logger.Error("Failed to read AnswerShippingQuery response", core.Field{"error", err})
 		return err
@@ -194,7 +264,7 @@ func (ps *paymentService) AnswerPreCheckoutQuery(ctx context.Context, preCheckou
 	}
 	defer resp.Body.Close()
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	bodyBytes, err := core.ReadLimited(resp.Body, ps.maxResponseSize)
 	if err != nil {
 		ps.logger.Error("Failed to read AnswerPreCheckoutQuery response", core.Field{"error", err})
 		return err
@@ -224,10 +294,36 @@ func (ps *paymentService) HandleSuccessfulPayment(ctx context.Context, update co
 }
 
 // ProcessRefund processes a refund for a given payment ID.
+// With WithIdempotencyWindow, a repeated refund of the same payment is not processed twice.
 func (ps *paymentService) ProcessRefund(ctx context.Context, paymentID string) error {
-	ps.logger.Info("Processing refund", core.Field{"payment_id", paymentID})
-	// Implement refund logic with the payment provider's API.
-	return nil
+	return ps.idempotent(ctx, "refund:"+paymentID, func() error {
+		ps.logger.Info("Processing refund", core.Field{"payment_id", paymentID})
+		// Implement refund logic with the payment provider's API.
+		return nil
+	})
+}
+
+// idempotent runs fn once per key within the idempotency window, or always when idempotency is disabled.
+// A duplicate caller waiting for the in-flight call returns ctx.Err() if ctx is done first.
+func (ps *paymentService) idempotent(ctx context.Context, key string, fn func() error) error {
+	if ps.idempotency == nil {
+		return fn()
+	}
+	entry, owner := ps.idempotency.acquire(key)
+	if !owner {
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if entry.err == nil {
+			ps.logger.Info("Skipping duplicate payment operation", core.Field{"key", key})
+		}
+		return entry.err
+	}
+	entry.err = fn()
+	ps.idempotency.complete(key, entry)
+	return entry.err
 }
 
 // GenerateReceipt generates a receipt for a given payment ID.
@@ -3,6 +3,7 @@ package payments
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -84,11 +85,67 @@ func TestAnswerShippingQuery(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := ps.AnswerShippingQuery(ctx, "test_query_id", true, ""); err != nil {
+	option := ShippingOption{ID: "courier", Title: "Courier", Prices: []Price{{Label: "Delivery", Amount: 500}}}
+	if err := ps.AnswerShippingQuery(ctx, "test_query_id", true, "", option); err != nil {
 		t.Errorf("AnswerShippingQuery returned error: %v", err)
 	}
 }
 
+func TestAnswerShippingQueryRequiresOptions(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+	}))
+	defer ts.Close()
+
+	ps := &paymentService{
+		token:      "TEST_TOKEN",
+		apiURL:     ts.URL,
+		httpClient: ts.Client(),
+		logger:     &dummyLogger{},
+	}
+
+	if err := ps.AnswerShippingQuery(context.Background(), "test_query_id", true, ""); !errors.Is(err, ErrShippingOptionsRequired) {
+		t.Fatalf("got %v, want ErrShippingOptionsRequired", err)
+	}
+	if requests != 0 {
+		t.Errorf("got %d requests, want none", requests)
+	}
+}
+
+func TestAnswerShippingQueryWithOptions(t *testing.T) {
+	var received map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+	}))
+	defer ts.Close()
+
+	ps := &paymentService{
+		token:      "TEST_TOKEN",
+		apiURL:     ts.URL,
+		httpClient: ts.Client(),
+		logger:     &dummyLogger{},
+	}
+
+	options := []ShippingOption{
+		{ID: "courier", Title: "Courier", Prices: []Price{{Label: "Delivery", Amount: 500}}},
+		{ID: "pickup", Title: "Pickup", Prices: []Price{{Label: "Pickup", Amount: 0}}},
+	}
+	if err := ps.AnswerShippingQuery(context.Background(), "test_query_id", true, "", options...); err != nil {
+		t.Fatalf("AnswerShippingQuery returned error: %v", err)
+	}
+
+	got, ok := received["shipping_options"].([]interface{})
+	if !ok || len(got) != len(options) {
+		t.Fatalf("expected %d shipping options in request, got %v", len(options), received["shipping_options"])
+	}
+	if _, exists := received["error_message"]; exists {
+		t.Error("error_message must not be sent for a positive answer")
+	}
+}
+
 func TestAnswerPreCheckoutQuery(t *testing.T) {
 	mockResp := map[string]interface{}{
 		"ok": true,
@@ -229,3 +286,60 @@ func TestGenerateReceipt(t *testing.T) {
 		}
 	}
 }
+
+func TestSendInvoiceIdempotency(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// Первая попытка завершается ошибкой и не должна запоминаться.
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+	}))
+	defer ts.Close()
+
+	ps := NewPaymentService("TEST_TOKEN", core.NewLogger(core.FatalLevel), ts.Client(), WithIdempotencyWindow(time.Minute)).(*paymentService)
+	ps.apiURL = ts.URL
+
+	invoice := Invoice{ChatID: 1, Payload: "order-1", Currency: CurrencyUSD, Prices: []Price{{Label: "Item", Amount: 100}}}
+	if err := ps.SendInvoice(context.Background(), invoice); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+	for i := 0; i < 3; i++ {
+		if err := ps.SendInvoice(context.Background(), invoice); err != nil {
+			t.Fatalf("SendInvoice: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("got %d sendInvoice requests, want 2", calls)
+	}
+
+	invoice.Payload = "order-2"
+	if err := ps.SendInvoice(context.Background(), invoice); err != nil || calls != 3 {
+		t.Errorf("different payload must be sent: err=%v calls=%d", err, calls)
+	}
+}
+
+func TestIdempotentDuplicateHonoursContext(t *testing.T) {
+	ps := NewPaymentService("TEST_TOKEN", core.NewLogger(core.FatalLevel), nil, WithIdempotencyWindow(time.Minute)).(*paymentService)
+	started, release := make(chan struct{}), make(chan struct{})
+	go ps.idempotent(context.Background(), "refund:p1", func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := ps.idempotent(ctx, "refund:p1", func() error {
+		t.Error("duplicate must not run while the first call is in flight")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,315 @@
+// Package testutil содержит вспомогательные средства для сквозного тестирования ботов.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+// FakeToken – токен, который FakeTelegram принимает по умолчанию.
+const FakeToken = "FAKE_TOKEN"
+
+// FakeBotID – ID бота, возвращаемый getMe.
+const FakeBotID = 1
+
+// Responder формирует поле result ответа на вызов метода Bot API. Ошибка превращается
+// в ответ {"ok":false} с кодом 400 и текстом ошибки в description.
+type Responder func(params map[string]interface{}) (interface{}, error)
+
+// SentMessage – сообщение, отправленное ботом через sendMessage.
+type SentMessage struct {
+	MessageID int
+	ChatID    int64
+	Text      string
+	// Params – все параметры запроса (parse_mode, reply_markup и т.д.).
+	Params map[string]interface{}
+}
+
+// AnsweredCallback – ответ бота на колбэк через answerCallbackQuery.
+type AnsweredCallback struct {
+	CallbackQueryID string
+	Text            string
+	ShowAlert       bool
+}
+
+// FakeTelegram – HTTP-сервер, имитирующий Bot API для сквозных тестов обработчиков.
+// Обновления, поставленные в очередь методами Enqueue*, отдаются через getUpdates с учётом offset;
+// sendMessage и answerCallbackQuery записываются и доступны через SentMessages и AnsweredCallbacks.
+// Остальные методы отвечают result = true, если для них не задан Responder (см. Handle).
+// Все вызовы доступны через Calls.
+//
+//	tg := testutil.NewFakeTelegram()
+//	defer tg.Close()
+//	api := tg.Client(logger)
+//	poller := core.NewPoller(api, router, logger)
+//	tg.EnqueueMessage(42, "/start")
+//	messages, ok := tg.WaitForMessages(1, 5*time.Second)
+type FakeTelegram struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	pending       []core.Update
+	nextUpdateID  int
+	nextMessageID int
+	sent          []SentMessage
+	answered      []AnsweredCallback
+	calls         []core.RecordedCall
+	responders    map[string]Responder
+}
+
+// NewFakeTelegram запускает фейковый сервер Bot API. Его нужно остановить вызовом Close.
+func NewFakeTelegram() *FakeTelegram {
+	f := &FakeTelegram{
+		nextUpdateID:  1,
+		nextMessageID: 1,
+		responders:    make(map[string]Responder),
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	return f
+}
+
+// Close останавливает сервер.
+func (f *FakeTelegram) Close() {
+	f.server.Close()
+}
+
+// BaseURL возвращает адрес сервера для core.WithBaseURL или files.WithBaseURL.
+func (f *FakeTelegram) BaseURL() string {
+	return f.server.URL
+}
+
+// Client создаёт клиент Bot API, направленный на этот сервер, с токеном FakeToken.
+func (f *FakeTelegram) Client(logger core.Logger, opts ...core.ClientOption) core.BotAPI {
+	opts = append([]core.ClientOption{core.WithBaseURL(f.BaseURL())}, opts...)
+	return core.NewBotClient(FakeToken, logger, f.server.Client(), opts...)
+}
+
+// Handle задаёт ответ на вызовы метода method, заменяя встроенное поведение.
+func (f *FakeTelegram) Handle(method string, responder Responder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responders[method] = responder
+}
+
+// EnqueueUpdate ставит обновление в очередь getUpdates. Нулевой UpdateID заменяется следующим
+// по порядку. Возвращает обновление в том виде, в каком его получит бот.
+func (f *FakeTelegram) EnqueueUpdate(update core.Update) core.Update {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if update.UpdateID == 0 {
+		update.UpdateID = f.nextUpdateID
+	}
+	if update.UpdateID >= f.nextUpdateID {
+		f.nextUpdateID = update.UpdateID + 1
+	}
+	f.pending = append(f.pending, update)
+	return update
+}
+
+// EnqueueMessage ставит в очередь текстовое сообщение пользователя chatID в личном чате с ботом.
+func (f *FakeTelegram) EnqueueMessage(chatID int64, text string) core.Update {
+	return f.EnqueueUpdate(core.Update{Message: &core.Message{
+		MessageID: f.messageID(),
+		From:      &core.User{ID: int(chatID), FirstName: "Test"},
+		Chat:      core.Chat{ID: chatID, Type: "private"},
+		Date:      time.Now().Unix(),
+		Text:      text,
+	}})
+}
+
+// EnqueueCallback ставит в очередь нажатие пользователем chatID inline-кнопки с данными data.
+func (f *FakeTelegram) EnqueueCallback(chatID int64, data string) core.Update {
+	return f.EnqueueUpdate(core.Update{CallbackQuery: &core.CallbackQuery{
+		ID:   "cb" + strconv.Itoa(f.messageID()),
+		From: core.User{ID: int(chatID), FirstName: "Test"},
+		Message: &core.Message{
+			MessageID: f.messageID(),
+			Chat:      core.Chat{ID: chatID, Type: "private"},
+		},
+		Data: data,
+	}})
+}
+
+// SentMessages возвращает сообщения, отправленные через sendMessage, в порядке отправки.
+func (f *FakeTelegram) SentMessages() []SentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SentMessage(nil), f.sent...)
+}
+
+// AnsweredCallbacks возвращает ответы на колбэки в порядке вызова answerCallbackQuery.
+func (f *FakeTelegram) AnsweredCallbacks() []AnsweredCallback {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]AnsweredCallback(nil), f.answered...)
+}
+
+// Calls возвращает все вызовы Bot API в порядке поступления.
+func (f *FakeTelegram) Calls() []core.RecordedCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]core.RecordedCall(nil), f.calls...)
+}
+
+// WaitForMessages ждёт, пока бот отправит не меньше n сообщений, но не дольше timeout.
+// Возвращает отправленные сообщения и false, если время истекло.
+func (f *FakeTelegram) WaitForMessages(n int, timeout time.Duration) ([]SentMessage, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		messages := f.SentMessages()
+		if len(messages) >= n {
+			return messages, true
+		}
+		if time.Now().After(deadline) {
+			return messages, false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// messageID выдаёт следующий идентификатор сообщения.
+func (f *FakeTelegram) messageID() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := f.nextMessageID
+	f.nextMessageID++
+	return id
+}
+
+// serveHTTP разбирает запрос вида /bot<token>/<method> и отвечает в формате Bot API.
+func (f *FakeTelegram) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/bot"+FakeToken+"/") {
+		writeResponse(w, http.StatusUnauthorized, map[string]interface{}{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+	method := path.Base(r.URL.Path)
+	params := readParams(r)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, core.RecordedCall{Method: method, Params: params})
+	responder := f.responders[method]
+	f.mu.Unlock()
+
+	var result interface{}
+	var err error
+	if responder != nil {
+		result, err = responder(params)
+	} else {
+		result = f.respond(method, params)
+	}
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, map[string]interface{}{"ok": false, "error_code": 400, "description": err.Error()})
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]interface{}{"ok": true, "result": result})
+}
+
+// respond реализует встроенное поведение методов.
+func (f *FakeTelegram) respond(method string, params map[string]interface{}) interface{} {
+	switch method {
+	case "getMe":
+		return core.User{ID: FakeBotID, IsBot: true, FirstName: "Fake Bot", Username: "fake_bot"}
+	case "getUpdates":
+		return f.takeUpdates(intParam(params["offset"]))
+	case "sendMessage":
+		msg := core.Message{
+			MessageID: f.messageID(),
+			Chat:      core.Chat{ID: intParam(params["chat_id"])},
+			Date:      time.Now().Unix(),
+			Text:      stringParam(params["text"]),
+		}
+		f.mu.Lock()
+		f.sent = append(f.sent, SentMessage{MessageID: msg.MessageID, ChatID: msg.Chat.ID, Text: msg.Text, Params: params})
+		f.mu.Unlock()
+		return msg
+	case "answerCallbackQuery":
+		answer := AnsweredCallback{
+			CallbackQueryID: stringParam(params["callback_query_id"]),
+			Text:            stringParam(params["text"]),
+			ShowAlert:       params["show_alert"] == true || params["show_alert"] == "true",
+		}
+		f.mu.Lock()
+		f.answered = append(f.answered, answer)
+		f.mu.Unlock()
+		return true
+	}
+	return true
+}
+
+// takeUpdates подтверждает обновления до offset и возвращает оставшиеся.
+func (f *FakeTelegram) takeUpdates(offset int64) []core.Update {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	kept := f.pending[:0]
+	for _, update := range f.pending {
+		if int64(update.UpdateID) >= offset {
+			kept = append(kept, update)
+		}
+	}
+	f.pending = kept
+	return append([]core.Update{}, kept...)
+}
+
+// readParams собирает параметры из строки запроса, JSON-тела и форм.
+func readParams(r *http.Request) map[string]interface{} {
+	params := make(map[string]interface{})
+	for key := range r.URL.Query() {
+		params[key] = r.URL.Query().Get(key)
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err == nil {
+			for key := range r.MultipartForm.Value {
+				params[key] = r.FormValue(key)
+			}
+		}
+		return params
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil || len(body) == 0 {
+		return params
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if decoder.Decode(&values) == nil {
+		for key, value := range values {
+			params[key] = value
+		}
+	}
+	return params
+}
+
+// intParam приводит числовой параметр из JSON или строки запроса к int64.
+func intParam(value interface{}) int64 {
+	switch v := value.(type) {
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// stringParam возвращает строковый параметр или пустую строку.
+func stringParam(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+func writeResponse(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
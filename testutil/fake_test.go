@@ -0,0 +1,50 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+func TestFakeTelegramEndToEnd(t *testing.T) {
+	tg := NewFakeTelegram()
+	defer tg.Close()
+	logger := core.NewLogger(core.FatalLevel)
+	api := tg.Client(logger)
+
+	router := core.NewRouter(logger)
+	router.HandleCommand("/start", func(update core.Update) error {
+		return api.SendMessage(update.Context(), core.ChatIDFromInt(update.Message.Chat.ID), "Welcome!")
+	})
+	router.HandleCallback("ok", func(update core.Update) error {
+		return api.AnswerCallbackQuery(update.Context(), update.CallbackQuery.ID, "Done", false)
+	})
+
+	tg.EnqueueMessage(42, "/start")
+	callback := tg.EnqueueCallback(42, "ok")
+
+	poller := core.NewPoller(api, router, logger)
+	if err := poller.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer poller.Wait()
+	defer poller.Stop()
+
+	messages, ok := tg.WaitForMessages(1, 5*time.Second)
+	if !ok {
+		t.Fatal("bot did not reply")
+	}
+	if messages[0].ChatID != 42 || messages[0].Text != "Welcome!" {
+		t.Errorf("unexpected message: %+v", messages[0])
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(tg.AnsweredCallbacks()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	answers := tg.AnsweredCallbacks()
+	if len(answers) != 1 || answers[0].CallbackQueryID != callback.CallbackQuery.ID || answers[0].Text != "Done" {
+		t.Errorf("unexpected callback answers: %+v", answers)
+	}
+}
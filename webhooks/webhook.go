@@ -4,9 +4,13 @@ import (
         "bytes"
         "context"
         "encoding/json"
+        "errors"
         "fmt"
         "io/ioutil"
+        "net"
         "net/http"
+        "net/url"
+        "sync"
         "time"
 
         "github.com/VVolf8/go-telegram-bot/core"
@@ -16,36 +20,251 @@ import (
 type WebhookManager interface {
         // SetWebhook устанавливает вебхук для бота по указанному URL.
         SetWebhook(ctx context.Context, webhookURL string) error
-        // DeleteWebhook удаляет текущий вебхук.
-        DeleteWebhook(ctx context.Context) error
+        // DeleteWebhook удаляет текущий вебхук. При dropPendingUpdates накопленные
+        // и ещё не доставленные обновления отбрасываются.
+        DeleteWebhook(ctx context.Context, dropPendingUpdates bool) error
         // ListenAndServe запускает HTTP-сервер для приёма обновлений через вебхук.
         // updateHandler вызывается для каждого полученного обновления.
         ListenAndServe(ctx context.Context, addr string, updateHandler func(ctx context.Context, update core.Update)) error
+        // Updates запускает HTTP-сервер на адресе из WithListenAddr и передаёт принятые
+        // обновления в возвращаемый канал (см. core.Serve). Канал закрывается после
+        // отмены ctx и остановки сервера.
+        core.UpdateSource
+}
+
+// DefaultMaxBodySize – ограничение размера тела запроса вебхука по умолчанию (2 МБ).
+// Обновления Telegram обычно занимают единицы килобайт.
+const DefaultMaxBodySize int64 = 2 << 20
+
+// Option задаёт необязательные параметры WebhookManager.
+type Option func(*webhookManager)
+
+// WithMaxBodySize задаёт максимальный размер тела входящего запроса в байтах.
+// Запросы большего размера отклоняются с кодом 413.
+func WithMaxBodySize(n int64) Option {
+        return func(w *webhookManager) {
+                w.maxBodySize = n
+        }
+}
+
+// WithUpdateTimeout ограничивает время обработки одного обновления: контекст, переданный
+// в updateHandler, отменяется через d после получения запроса. Обработчик должен использовать
+// этот контекст в своих запросах, чтобы работа действительно прерывалась. По умолчанию
+// ограничение не задано и действует только контекст HTTP-запроса.
+func WithUpdateTimeout(d time.Duration) Option {
+        return func(w *webhookManager) {
+                w.updateTimeout = d
+        }
+}
+
+// WithAuditSink сохраняет тело каждого принятого обновления в sink до вызова обработчика.
+// Повторно доставленные обновления, отброшенные WithReplayProtection, не записываются.
+func WithAuditSink(sink core.AuditSink) Option {
+        return func(w *webhookManager) {
+                w.audit = sink
+        }
+}
+
+// WithListenAddr задаёт адрес HTTP-сервера, запускаемого Updates, например ":8443".
+func WithListenAddr(addr string) Option {
+        return func(w *webhookManager) {
+                w.listenAddr = addr
+        }
+}
+
+// WithBotAPI добавляет api в контекст каждого обновления, передаваемого в updateHandler,
+// чтобы обработчики могли получить клиент через core.APIFromContext.
+func WithBotAPI(api core.BotAPI) Option {
+        return func(w *webhookManager) {
+                w.api = api
+        }
+}
+
+// WithReplayProtection включает отбрасывание повторно доставленных обновлений: вебхук помнит
+// последние size принятых update_id и на повтор отвечает 200 OK, не вызывая обработчик.
+func WithReplayProtection(size int) Option {
+        return func(w *webhookManager) {
+                if size > 0 {
+                        w.recent = newRecentIDs(size)
+                }
+        }
+}
+
+// recentIDs – кольцевой буфер последних принятых update_id.
+type recentIDs struct {
+        mu   sync.Mutex
+        ring []int
+        next int
+        seen map[int]struct{}
+}
+
+func newRecentIDs(size int) *recentIDs {
+        return &recentIDs{
+                ring: make([]int, 0, size),
+                seen: make(map[int]struct{}, size),
+        }
+}
+
+// add запоминает id и возвращает false, если он уже был принят.
+func (r *recentIDs) add(id int) bool {
+        r.mu.Lock()
+        defer r.mu.Unlock()
+        if _, ok := r.seen[id]; ok {
+                return false
+        }
+        if len(r.ring) < cap(r.ring) {
+                r.ring = append(r.ring, id)
+        } else {
+                delete(r.seen, r.ring[r.next])
+                r.ring[r.next] = id
+                r.next = (r.next + 1) % len(r.ring)
+        }
+        r.seen[id] = struct{}{}
+        return true
+}
+
+// maxRetryBackoff ограничивает паузу между повторными попытками.
+const maxRetryBackoff = 30 * time.Second
+
+// WithRetry включает повтор SetWebhook и DeleteWebhook при временных сбоях (сетевые ошибки,
+// ответы 429 и 5xx): всего выполняется до maxAttempts попыток, пауза перед первым повтором
+// равна initialBackoff и удваивается с каждой попыткой (но не более 30 секунд).
+// По умолчанию выполняется одна попытка.
+func WithRetry(maxAttempts int, initialBackoff time.Duration) Option {
+        return func(w *webhookManager) {
+                if maxAttempts > 0 {
+                        w.maxAttempts = maxAttempts
+                }
+                w.initialBackoff = initialBackoff
+        }
 }
 
 // webhookManager – реализация WebhookManager.
 type webhookManager struct {
-        token      string
-        apiURL     string
-        httpClient *http.Client
-        logger     core.Logger
+        token       string
+        apiURL      string
+        httpClient  *http.Client
+        logger      core.Logger
+        maxBodySize int64
+        // maxAttempts и initialBackoff управляют повторами запросов к Bot API.
+        maxAttempts    int
+        initialBackoff time.Duration
+        // recent хранит недавние update_id при включённой защите от повторов.
+        recent *recentIDs
+        // updateTimeout ограничивает время обработки одного обновления; 0 – без ограничения.
+        updateTimeout time.Duration
+        // audit получает исходный JSON принятых обновлений.
+        audit core.AuditSink
+        // api добавляется в контекст обновлений (см. core.APIFromContext).
+        api core.BotAPI
+        // listenAddr – адрес сервера для Updates.
+        listenAddr string
+}
+
+// statusError – ответ Bot API с кодом, отличным от 200.
+type statusError struct {
+        method string
+        status string
+        code   int
+}
+
+func (e *statusError) Error() string {
+        return fmt.Sprintf("%s failed with status: %s", e.method, e.status)
+}
+
+// isTransient сообщает, имеет ли смысл повторить запрос после ошибки err.
+func isTransient(err error) bool {
+        var se *statusError
+        if errors.As(err, &se) {
+                return se.code == http.StatusTooManyRequests || se.code >= 500
+        }
+        return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// withRetry выполняет fn, повторяя её при временных ошибках согласно настройкам WithRetry.
+func (w *webhookManager) withRetry(ctx context.Context, method string, fn func() error) error {
+        backoff := w.initialBackoff
+        for attempt := 1; ; attempt++ {
+                err := fn()
+                if err == nil || attempt >= w.maxAttempts || !isTransient(err) {
+                        return err
+                }
+                w.logger.Warn("Retrying "+method+" after error",
+                        core.Field{"attempt", attempt},
+                        core.Field{"backoff", backoff.String()},
+                        core.Field{"error", err},
+                )
+                select {
+                case <-ctx.Done():
+                        return ctx.Err()
+                case <-time.After(backoff):
+                }
+                backoff *= 2
+                if backoff > maxRetryBackoff {
+                        backoff = maxRetryBackoff
+                }
+        }
 }
 
 // NewWebhookManager создаёт новый экземпляр WebhookManager с использованием переданного токена и логгера.
-func NewWebhookManager(token string, logger core.Logger) WebhookManager {
+func NewWebhookManager(token string, logger core.Logger, opts ...Option) WebhookManager {
         if logger == nil {
                 logger = core.NewDefaultLogger()
         }
-        return &webhookManager{
-                token:      token,
-                apiURL:     fmt.Sprintf("https://api.telegram.org/bot%s", token),
-                httpClient: &http.Client{},
-                logger:     logger,
+        w := &webhookManager{
+                token:       token,
+                apiURL:      fmt.Sprintf("https://api.telegram.org/bot%s", token),
+                httpClient:  &http.Client{},
+                logger:      logger,
+                maxBodySize: DefaultMaxBodySize,
+                maxAttempts: 1,
         }
+        for _, opt := range opts {
+                opt(w)
+        }
+        return w
+}
+
+// ErrInvalidWebhookURL возвращается SetWebhook, если URL не подходит для вебхука Telegram.
+var ErrInvalidWebhookURL = errors.New("invalid webhook URL")
+
+// allowedWebhookPorts – порты, на которые Telegram отправляет запросы вебхука.
+var allowedWebhookPorts = map[string]bool{"443": true, "80": true, "88": true, "8443": true}
+
+// ValidateWebhookURL проверяет, что webhookURL – абсолютный HTTPS-адрес с портом,
+// поддерживаемым Telegram (443, 80, 88 или 8443). Ошибка оборачивает ErrInvalidWebhookURL.
+func ValidateWebhookURL(webhookURL string) error {
+        u, err := url.Parse(webhookURL)
+        if err != nil {
+                return fmt.Errorf("%w: %v", ErrInvalidWebhookURL, err)
+        }
+        if u.Scheme != "https" {
+                return fmt.Errorf("%w: scheme must be https, got %q", ErrInvalidWebhookURL, u.Scheme)
+        }
+        if u.Hostname() == "" {
+                return fmt.Errorf("%w: host is empty", ErrInvalidWebhookURL)
+        }
+        if port := u.Port(); port != "" && !allowedWebhookPorts[port] {
+                return fmt.Errorf("%w: port %s is not supported, use 443, 80, 88 or 8443", ErrInvalidWebhookURL, port)
+        }
+        return nil
 }
 
 // SetWebhook устанавливает вебхук для бота.
+// URL предварительно проверяется ValidateWebhookURL, чтобы не отправлять заведомо неверный запрос.
+// При включённой опции WithRetry временные сбои повторяются с экспоненциальной паузой.
 func (w *webhookManager) SetWebhook(ctx context.Context, webhookURL string) error {
+        if err := ValidateWebhookURL(webhookURL); err != nil {
+                w.logger.Error("Invalid webhook URL", core.Field{"webhook_url", webhookURL}, core.Field{"error", err})
+                return err
+        }
+        return w.withRetry(ctx, "setWebhook", func() error {
+                return w.setWebhook(ctx, webhookURL)
+        })
+}
+
+// setWebhook выполняет одну попытку вызова setWebhook.
+func (w *webhookManager) setWebhook(ctx context.Context, webhookURL string) error {
         endpoint := fmt.Sprintf("%s/setWebhook", w.apiURL)
         payload := map[string]interface{}{
                 "url": webhookURL,
@@ -73,22 +292,34 @@ func (w *webhookManager) SetWebhook(ctx context.Context, webhookURL string) erro
         }
         defer resp.Body.Close()
 
-        respBody, _ := ioutil.ReadAll(resp.Body)
+        respBody, _ := core.ReadLimited(resp.Body, core.DefaultMaxResponseSize)
         if resp.StatusCode != http.StatusOK {
                 w.logger.Error("Non-OK response from setWebhook",
                         core.Field{"status", resp.Status},
                         core.Field{"body", string(respBody)},
                 )
-                return fmt.Errorf("setWebhook failed with status: %s", resp.Status)
+                return &statusError{method: "setWebhook", status: resp.Status, code: resp.StatusCode}
         }
 
         w.logger.Info("Webhook set successfully", core.Field{"webhook_url", webhookURL})
         return nil
 }
 
-// DeleteWebhook удаляет текущий вебхук.
-func (w *webhookManager) DeleteWebhook(ctx context.Context) error {
+// DeleteWebhook удаляет текущий вебхук. Если dropPendingUpdates равен true, Telegram отбрасывает
+// накопленные обновления, что удобно при переходе с вебхука на поллинг.
+// При включённой опции WithRetry временные сбои повторяются с экспоненциальной паузой.
+func (w *webhookManager) DeleteWebhook(ctx context.Context, dropPendingUpdates bool) error {
+        return w.withRetry(ctx, "deleteWebhook", func() error {
+                return w.deleteWebhook(ctx, dropPendingUpdates)
+        })
+}
+
+// deleteWebhook выполняет одну попытку вызова deleteWebhook.
+func (w *webhookManager) deleteWebhook(ctx context.Context, dropPendingUpdates bool) error {
         endpoint := fmt.Sprintf("%s/deleteWebhook", w.apiURL)
+        if dropPendingUpdates {
+                endpoint += "?drop_pending_updates=true"
+        }
         req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
         if err != nil {
                 w.logger.Error("Failed to create deleteWebhook request", core.Field{"error", err})
@@ -105,13 +336,13 @@ func (w *webhookManager) DeleteWebhook(ctx context.Context) error {
         }
         defer resp.Body.Close()
 
-        respBody, _ := ioutil.ReadAll(resp.Body)
+        respBody, _ := core.ReadLimited(resp.Body, core.DefaultMaxResponseSize)
         if resp.StatusCode != http.StatusOK {
                 w.logger.Error("Non-OK response from deleteWebhook",
                         core.Field{"status", resp.Status},
                         core.Field{"body", string(respBody)},
                 )
-                return fmt.Errorf("deleteWebhook failed with status: %s", resp.Status)
+                return &statusError{method: "deleteWebhook", status: resp.Status, code: resp.StatusCode}
         }
 
         w.logger.Info("Webhook deleted successfully")
@@ -120,18 +351,112 @@ func (w *webhookManager) DeleteWebhook(ctx context.Context) error {
 
 // ListenAndServe запускает HTTP-сервер для приёма обновлений через вебхук.
 // updateHandler вызывается для каждого обновления, полученного в POST-запросе.
+// Адрес занимается синхронно: если порт занят или адрес некорректен, ошибка возвращается сразу.
 func (w *webhookManager) ListenAndServe(ctx context.Context, addr string, updateHandler func(ctx context.Context, update core.Update)) error {
+        ln, err := w.listen(addr)
+        if err != nil {
+                return err
+        }
+        return w.serve(ctx, ln, updateHandler)
+}
+
+// listen занимает адрес addr для сервера вебхука.
+func (w *webhookManager) listen(addr string) (net.Listener, error) {
+        ln, err := net.Listen("tcp", addr)
+        if err != nil {
+                w.logger.Error("Failed to listen for webhook updates", core.Field{"addr", addr}, core.Field{"error", err})
+                return nil, err
+        }
+        return ln, nil
+}
+
+// serve обслуживает запросы на ln до отмены ctx, после чего корректно останавливает сервер.
+func (w *webhookManager) serve(ctx context.Context, ln net.Listener, updateHandler func(ctx context.Context, update core.Update)) error {
         // Создаем мультиплексор для обработки запросов.
         mux := http.NewServeMux()
-        mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+        mux.HandleFunc("/", w.handleUpdates(updateHandler))
+
+        server := &http.Server{
+                Handler: mux,
+        }
+
+        // Запускаем сервер в отдельной горутине.
+        go func() {
+                w.logger.Info("Starting webhook server", core.Field{"addr", ln.Addr().String()})
+                if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+                        w.logger.Error("Webhook server error", core.Field{"error", err})
+                }
+        }()
+
+        // Ожидаем отмены контекста для корректного завершения работы сервера.
+        <-ctx.Done()
+        w.logger.Info("Shutting down webhook server")
+        shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        return server.Shutdown(shutdownCtx)
+}
+
+// ErrNoListenAddr возвращается Updates, если адрес сервера не задан опцией WithListenAddr.
+var ErrNoListenAddr = errors.New("webhook listen address is not set")
+
+// Updates запускает ListenAndServe на адресе listenAddr и передаёт обновления в канал.
+// Ошибка занятия адреса (например, порт уже используется) возвращается сразу.
+// Telegram получает ответ, как только обновление принято из канала, поэтому контекст
+// обновления строится от ctx, а не от HTTP-запроса: он сохраняет correlation ID и BotAPI
+// (WithBotAPI), но не ограничивается WithUpdateTimeout.
+func (w *webhookManager) Updates(ctx context.Context) (<-chan core.Update, error) {
+        if w.listenAddr == "" {
+                return nil, ErrNoListenAddr
+        }
+        if err := ctx.Err(); err != nil {
+                return nil, err
+        }
+        ln, err := w.listen(w.listenAddr)
+        if err != nil {
+                return nil, err
+        }
+        updates := make(chan core.Update)
+        handler := func(reqCtx context.Context, update core.Update) {
+                updateCtx := core.ContextWithCorrelationID(ctx, core.CorrelationIDFromContext(reqCtx))
+                if w.api != nil {
+                        updateCtx = core.ContextWithAPI(updateCtx, w.api)
+                }
+                select {
+                case updates <- update.WithContext(updateCtx):
+                case <-ctx.Done():
+                        core.LoggerWithCorrelation(w.logger, updateCtx).Warn("Webhook update dropped on shutdown", core.Field{"update_id", update.UpdateID})
+                }
+        }
+        go func() {
+                // Shutdown дожидается активных обработчиков, поэтому после него в канал никто не пишет.
+                defer close(updates)
+                if err := w.serve(ctx, ln, handler); err != nil {
+                        w.logger.Error("Webhook server shutdown failed", core.Field{"error", err})
+                }
+        }()
+        return updates, nil
+}
+
+// handleUpdates возвращает HTTP-обработчик, принимающий обновления от Telegram
+// и передающий их в updateHandler.
+func (w *webhookManager) handleUpdates(updateHandler func(ctx context.Context, update core.Update)) http.HandlerFunc {
+        return func(rw http.ResponseWriter, req *http.Request) {
                 // Обрабатываем только POST-запросы.
                 if req.Method != http.MethodPost {
                         rw.WriteHeader(http.StatusMethodNotAllowed)
                         return
                 }
 
+                // Ограничиваем размер тела, чтобы слишком большой запрос не исчерпал память.
+                req.Body = http.MaxBytesReader(rw, req.Body, w.maxBodySize)
                 body, err := ioutil.ReadAll(req.Body)
                 if err != nil {
+                        var maxBytesErr *http.MaxBytesError
+                        if errors.As(err, &maxBytesErr) {
+                                w.logger.Warn("Webhook request body too large", core.Field{"limit", w.maxBodySize})
+                                rw.WriteHeader(http.StatusRequestEntityTooLarge)
+                                return
+                        }
                         w.logger.Error("Failed to read webhook request body", core.Field{"error", err})
                         rw.WriteHeader(http.StatusBadRequest)
                         return
@@ -145,35 +470,45 @@ func (w *webhookManager) ListenAndServe(ctx context.Context, addr string, update
                         return
                 }
 
-                w.logger.Info("Webhook update received", core.Field{"update_id", update.UpdateID})
+                // Повторно доставленное обновление подтверждаем, но не обрабатываем.
+                if w.recent != nil && !w.recent.add(update.UpdateID) {
+                        w.logger.Debug("Ignoring already accepted webhook update", core.Field{"update_id", update.UpdateID})
+                        rw.WriteHeader(http.StatusOK)
+                        return
+                }
+
+                // Каждое обновление получает собственный correlation ID для сквозного логирования.
+                ctx := core.ContextWithCorrelationID(req.Context(), core.NewCorrelationID())
+                if w.api != nil {
+                        ctx = core.ContextWithAPI(ctx, w.api)
+                }
+                if w.updateTimeout > 0 {
+                        var cancel context.CancelFunc
+                        ctx, cancel = context.WithTimeout(ctx, w.updateTimeout)
+                        defer cancel()
+                }
+                update = update.WithContext(ctx)
+                logger := core.LoggerWithCorrelation(w.logger, ctx)
+                logger.Info("Webhook update received", core.Field{"update_id", update.UpdateID})
+                if w.audit != nil {
+                        if err := w.audit.Record(ctx, body); err != nil {
+                                logger.Error("Failed to record update for audit", core.Field{"update_id", update.UpdateID}, core.Field{"error", err})
+                        }
+                }
 
                 // Вызываем обработчик обновления с защитой от паники.
-                core.WithRecovery(w.logger, func() {
-                        updateHandler(req.Context(), update)
+                core.WithRecovery(logger, func() {
+                        updateHandler(ctx, update)
                 })
+                if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+                        logger.Warn("Webhook update handler exceeded its timeout",
+                                core.Field{"update_id", update.UpdateID},
+                                core.Field{"timeout", w.updateTimeout.String()},
+                        )
+                }
 
                 // Отправляем ответ Telegram.
                 rw.WriteHeader(http.StatusOK)
                 rw.Write([]byte("OK"))
-        })
-
-        server := &http.Server{
-                Addr:    addr,
-                Handler: mux,
         }
-
-        // Запускаем сервер в отдельной горутине.
-        go func() {
-                w.logger.Info("Starting webhook server", core.Field{"addr", addr})
-                if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-                        w.logger.Error("Webhook server error", core.Field{"error", err})
-                }
-        }()
-
-        // Ожидаем отмены контекста для корректного завершения работы сервера.
-        <-ctx.Done()
-        w.logger.Info("Shutting down webhook server")
-        shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-        defer cancel()
-        return server.Shutdown(shutdownCtx)
 }
@@ -0,0 +1,179 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VVolf8/go-telegram-bot/core"
+)
+
+func TestSetWebhookRetriesTransientFailures(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			rw.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		rw.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer ts.Close()
+
+	w := NewWebhookManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), WithRetry(5, 0)).(*webhookManager)
+	w.apiURL = ts.URL
+	if err := w.SetWebhook(context.Background(), "https://example.com/hook"); err != nil {
+		t.Fatalf("SetWebhook: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+}
+
+func TestDeleteWebhookDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		attempts++
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	w := NewWebhookManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), WithRetry(5, 0)).(*webhookManager)
+	w.apiURL = ts.URL
+	if err := w.DeleteWebhook(context.Background(), false); err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+}
+
+func TestReplayProtectionIgnoresSeenUpdates(t *testing.T) {
+	w := NewWebhookManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), WithReplayProtection(2)).(*webhookManager)
+	var handled []int
+	handler := w.handleUpdates(func(ctx context.Context, update core.Update) {
+		handled = append(handled, update.UpdateID)
+	})
+
+	for _, id := range []int{1, 2, 1, 3, 1, 3} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(fmt.Sprintf(`{"update_id":%d}`, id)))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("update %d: got status %d", id, rec.Code)
+		}
+	}
+	// Буфер хранит два последних id, поэтому 1 снова принимается после вытеснения.
+	want := []int{1, 2, 3, 1}
+	if fmt.Sprint(handled) != fmt.Sprint(want) {
+		t.Errorf("handled %v, want %v", handled, want)
+	}
+}
+
+func TestUpdateTimeoutBoundsHandlerContext(t *testing.T) {
+	w := NewWebhookManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), WithUpdateTimeout(20*time.Millisecond)).(*webhookManager)
+	var handlerErr error
+	handler := w.handleUpdates(func(ctx context.Context, update core.Update) {
+		if _, ok := update.Context().Deadline(); !ok {
+			t.Error("update context has no deadline")
+		}
+		<-ctx.Done()
+		handlerErr = ctx.Err()
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"update_id":1}`))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d", rec.Code)
+	}
+	if handlerErr != context.DeadlineExceeded {
+		t.Errorf("handler context error = %v, want DeadlineExceeded", handlerErr)
+	}
+}
+
+// auditRecorder – AuditSink, запоминающий записанные обновления.
+type auditRecorder struct {
+	records []string
+}
+
+func (a *auditRecorder) Record(ctx context.Context, raw json.RawMessage) error {
+	a.records = append(a.records, string(raw))
+	return nil
+}
+
+func TestAuditSinkRecordsBeforeHandling(t *testing.T) {
+	audit := &auditRecorder{}
+	w := NewWebhookManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), WithAuditSink(audit)).(*webhookManager)
+	handler := w.handleUpdates(func(ctx context.Context, update core.Update) {
+		if len(audit.records) != 1 {
+			t.Errorf("handler called before the update was recorded")
+		}
+	})
+
+	body := `{"update_id":7,"message":{"message_id":1,"chat":{"id":1}}}`
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	if len(audit.records) != 1 || audit.records[0] != body {
+		t.Errorf("unexpected audit records: %v", audit.records)
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	valid := []string{"https://example.com/hook", "https://example.com:8443/hook", "https://1.2.3.4:88/"}
+	for _, u := range valid {
+		if err := ValidateWebhookURL(u); err != nil {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want nil", u, err)
+		}
+	}
+	invalid := []string{"http://example.com/hook", "example.com/hook", "https:///hook", "https://example.com:8080/hook"}
+	for _, u := range invalid {
+		if err := ValidateWebhookURL(u); !errors.Is(err, ErrInvalidWebhookURL) {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want ErrInvalidWebhookURL", u, err)
+		}
+	}
+}
+
+func TestDeleteWebhookDropPendingUpdates(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		rw.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer ts.Close()
+
+	w := NewWebhookManager("TEST_TOKEN", core.NewLogger(core.FatalLevel)).(*webhookManager)
+	w.apiURL = ts.URL
+	if err := w.DeleteWebhook(context.Background(), true); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if query != "drop_pending_updates=true" {
+		t.Errorf("query = %q, want drop_pending_updates=true", query)
+	}
+}
+
+func TestListenErrorIsReturned(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	addr := busy.Addr().String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w := NewWebhookManager("TEST_TOKEN", core.NewLogger(core.FatalLevel), WithListenAddr(addr))
+	if updates, err := w.Updates(ctx); err == nil || updates != nil {
+		t.Errorf("Updates on a busy port returned %v, %v; want an error", updates, err)
+	}
+	if err := w.ListenAndServe(ctx, addr, func(context.Context, core.Update) {}); err == nil {
+		t.Error("ListenAndServe on a busy port returned nil error")
+	}
+}